@@ -0,0 +1,78 @@
+package flatten
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// AnsibleExtraVars returns ansible-playbook arguments passing each leaf of vars, in key order, e.g.
+//
+//	-e app.port=8080 -e app.name="my app"
+//
+// Keys are joined with DotStyle.  A value with spaces, quotes or backslashes is double-quoted, as ansible
+// itself splits key=value arguments at whitespace.  The arguments suit exec.Command; see
+// AnsibleExtraVarsLine for a shell.
+func AnsibleExtraVars(vars map[string]interface{}) ([]string, error) {
+	flat, err := Flatten(vars, "", DotStyle)
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, 0, 2*len(flat))
+	for _, k := range sortedKeys(flat) {
+		v := formatValue(flat[k])
+		if strings.ContainsAny(v, " \t\n'\"\\") {
+			v = ansibleQuote(v)
+		}
+		args = append(args, "-e", k+"="+v)
+	}
+
+	return args, nil
+}
+
+// AnsibleExtraVarsLine is AnsibleExtraVars joined into one line for a POSIX shell, with each argument
+// single-quoted as needed, e.g. -e app.port=8080 -e 'app.name="my app"'.
+func AnsibleExtraVarsLine(vars map[string]interface{}) (string, error) {
+	args, err := AnsibleExtraVars(vars)
+	if err != nil {
+		return "", err
+	}
+
+	for i, arg := range args {
+		args[i] = shellQuote(arg)
+	}
+
+	return strings.Join(args, " "), nil
+}
+
+// AnsibleExtraVarsJSON returns the single flat JSON object of vars, with keys joined with DotStyle, as
+// given to ansible-playbook --extra-vars, e.g. {"app.port":8080}.  Unlike the key=value form, it keeps
+// the types of values.
+func AnsibleExtraVarsJSON(vars map[string]interface{}) (string, error) {
+	flat, err := Flatten(vars, "", DotStyle)
+	if err != nil {
+		return "", err
+	}
+
+	flatb, err := json.Marshal(&flat)
+	if err != nil {
+		return "", err
+	}
+
+	return string(flatb), nil
+}
+
+// ansibleQuote quotes a value for ansible's own key=value parsing, which follows shell-like rules.
+func ansibleQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// shellQuote quotes s, if needed, as a single word for a POSIX shell.
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, shellSafe) == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'"'"'`) + "'"
+}
+
+const shellSafe = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789@%+=:,./-_"
@@ -0,0 +1,57 @@
+package flatten
+
+import (
+	"reflect"
+	"testing"
+)
+
+var ansibleVars = map[string]interface{}{
+	"app": map[string]interface{}{
+		"port":  8080.0,
+		"name":  "my app",
+		"hosts": []interface{}{"a", "b"},
+	},
+	"quote": `it's "x"`,
+}
+
+func TestAnsibleExtraVars(t *testing.T) {
+	got, err := AnsibleExtraVars(ansibleVars)
+	if err != nil {
+		t.Fatalf("failed to build args: %v", err)
+	}
+
+	want := []string{
+		"-e", "app.hosts.0=a",
+		"-e", "app.hosts.1=b",
+		"-e", `app.name="my app"`,
+		"-e", "app.port=8080",
+		"-e", `quote="it's \"x\""`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %q wanted: %q", got, want)
+	}
+}
+
+func TestAnsibleExtraVarsLine(t *testing.T) {
+	got, err := AnsibleExtraVarsLine(ansibleVars)
+	if err != nil {
+		t.Fatalf("failed to build line: %v", err)
+	}
+
+	want := `-e app.hosts.0=a -e app.hosts.1=b -e 'app.name="my app"' -e app.port=8080 -e 'quote="it'"'"'s \"x\""'`
+	if got != want {
+		t.Errorf("mismatch, got: %v wanted: %v", got, want)
+	}
+}
+
+func TestAnsibleExtraVarsJSON(t *testing.T) {
+	got, err := AnsibleExtraVarsJSON(ansibleVars)
+	if err != nil {
+		t.Fatalf("failed to build JSON: %v", err)
+	}
+
+	want := `{"app.hosts.0":"a","app.hosts.1":"b","app.name":"my app","app.port":8080,"quote":"it's \"x\""}`
+	if got != want {
+		t.Errorf("mismatch, got: %v wanted: %v", got, want)
+	}
+}
@@ -0,0 +1,91 @@
+package flatten
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Distinct paths made the same flattened key
+var KeyCollisionError = errors.New("Not a valid input: distinct paths make the same key")
+
+// A CollisionError reports a flattened key made by distinct paths.  It is a KeyCollisionError, to
+// errors.Is.
+type CollisionError struct {
+	Key   string   // The flattened key
+	Paths []string // The paths making it, as jq writes them, e.g. .a."b c"[0]
+}
+
+func (e *CollisionError) Error() string {
+	return fmt.Sprintf("%v: %q, from %s", KeyCollisionError, e.Key, strings.Join(e.Paths, " and "))
+}
+
+func (e *CollisionError) Unwrap() error {
+	return KeyCollisionError
+}
+
+// A CollisionPolicy says what flattening does when distinct paths make the same key, as "a.b" does for
+// both {"a": {"b": 1}} and {"a.b": 2} in DotStyle.
+type CollisionPolicy int
+
+const (
+	OverwriteOnCollision CollisionPolicy = iota // Keep one of the values, silently
+	ErrorOnCollision                            // Fail, once flattened, with a *CollisionError for each key
+	KeepFirst                                   // Keep the value of the first path, in sorted order of map keys
+	KeepLast                                    // Keep the value of the last path, in sorted order of map keys
+	CollectCollisions                           // Keep a slice of the values, in sorted order of map keys
+)
+
+// collide notes that the current path made key, returning the value to set there, or false to leave
+// it, if an earlier path made it too, as the policy says.  A key already in the map, as FlattenInto
+// may find one, counts as made first.
+func (f *flattener) collide(flatMap map[string]interface{}, key string, v interface{}) (interface{}, bool) {
+	if f.origins == nil {
+		f.origins = make(map[string][]interface{})
+	}
+	prior, exists := flatMap[key]
+	if !exists {
+		f.origins[key] = copyPath(f.path)
+		return v, true
+	}
+
+	if f.collisions == nil {
+		f.collisions = make(map[string][]string)
+	}
+	paths, collided := f.collisions[key]
+	if !collided {
+		first := "(already in the map)"
+		if path, ok := f.origins[key]; ok {
+			first = pathString(path)
+		}
+		paths = []string{first}
+	}
+	f.collisions[key] = append(paths, pathString(f.path))
+
+	switch f.opts.Collisions {
+	case KeepFirst:
+		return nil, false
+	case CollectCollisions:
+		if list, ok := prior.([]interface{}); collided && ok {
+			return append(list, v), true
+		}
+		return []interface{}{prior, v}, true
+	}
+	return v, true
+}
+
+// collisionError returns the collisions noted, joined in key order, or nil if there were none.
+func (f *flattener) collisionError() error {
+	keys := make([]string, 0, len(f.collisions))
+	for k := range f.collisions {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	errs := make([]error, len(keys))
+	for i, k := range keys {
+		errs[i] = &CollisionError{Key: k, Paths: f.collisions[k]}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,161 @@
+package flatten
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestErrorOnCollision(t *testing.T) {
+	nested := map[string]interface{}{
+		"a":     map[string]interface{}{"b": 1},
+		"a.b":   2,
+		"x":     []interface{}{"i", "j"},
+		"x.1":   "k",
+		"p":     map[string]interface{}{"q.r": 3, "q": map[string]interface{}{"r": 4}},
+		"p.q.r": 5,
+	}
+
+	got, err := Flatten(nested, "", DotStyle)
+	if err != nil || len(got) != 4 {
+		t.Errorf("mismatch, got: %v, %v wanted 4 keys, silently", got, err)
+	}
+
+	_, err = Options{Collisions: ErrorOnCollision}.Flatten(nested, "", DotStyle)
+	if !errors.Is(err, KeyCollisionError) {
+		t.Fatalf("error mismatch, got: [%v], wanted: [%v]", err, KeyCollisionError)
+	}
+	var collisions []*CollisionError
+	for _, err := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var collision *CollisionError
+		if errors.As(err, &collision) {
+			collisions = append(collisions, collision)
+		}
+	}
+	if len(collisions) != 3 || collisions[0].Key != "a.b" || collisions[1].Key != "p.q.r" || collisions[2].Key != "x.1" {
+		t.Fatalf("mismatch, got: %v wanted collisions at a.b, p.q.r and x.1", collisions)
+	}
+	if got, want := len(collisions[1].Paths), 3; got != want {
+		t.Errorf("mismatch, got: %v wanted %d paths", collisions[1].Paths, want)
+	}
+
+	got, err = Options{Collisions: ErrorOnCollision}.Flatten(map[string]interface{}{"a": map[string]interface{}{"b": 1}, "c": 2}, "", DotStyle)
+	if want := map[string]interface{}{"a.b": 1, "c": 2}; err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %v, %v wanted: %v", got, err, want)
+	}
+}
+
+func TestCollisionPolicies(t *testing.T) {
+	// In sorted order of map keys, "a" comes before "a.b", so its path first.
+	nested := map[string]interface{}{
+		"a":   map[string]interface{}{"b": 1},
+		"a.b": 2,
+		"c":   3,
+	}
+
+	cases := []struct {
+		policy CollisionPolicy
+		want   map[string]interface{}
+	}{
+		// 1
+		{KeepFirst, map[string]interface{}{"a.b": 1, "c": 3}},
+		// 2
+		{KeepLast, map[string]interface{}{"a.b": 2, "c": 3}},
+		// 3
+		{CollectCollisions, map[string]interface{}{"a.b": []interface{}{1, 2}, "c": 3}},
+	}
+
+	for i, test := range cases {
+		for n := 0; n < 10; n++ { // against luck in map order
+			got, err := Options{Collisions: test.policy}.Flatten(nested, "", DotStyle)
+			if err != nil {
+				t.Fatalf("%d: failed to flatten: %v", i+1, err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("%d: mismatch, got: %v wanted: %v", i+1, got, test.want)
+			}
+		}
+	}
+}
+
+func TestFlattenInto(t *testing.T) {
+	nested := map[string]interface{}{"a": map[string]interface{}{"b": 1}, "c": 2}
+
+	cases := []struct {
+		opts Options
+		want map[string]interface{}
+	}{
+		// 1
+		{Options{}, map[string]interface{}{"a.b": 1, "c": 2, "d": 0}},
+		// 2
+		{Options{Collisions: KeepFirst}, map[string]interface{}{"a.b": 0, "c": 2, "d": 0}},
+		// 3
+		{Options{Collisions: KeepLast}, map[string]interface{}{"a.b": 1, "c": 2, "d": 0}},
+		// 4
+		{Options{Collisions: CollectCollisions}, map[string]interface{}{"a.b": []interface{}{0, 1}, "c": 2, "d": 0}},
+	}
+
+	for i, test := range cases {
+		dst := map[string]interface{}{"a.b": 0, "d": 0}
+		if err := test.opts.FlattenInto(dst, nested, "", DotStyle); err != nil {
+			t.Errorf("%d: failed to flatten: %v", i+1, err)
+			continue
+		}
+		if !reflect.DeepEqual(dst, test.want) {
+			t.Errorf("%d: mismatch, got: %v wanted: %v", i+1, dst, test.want)
+		}
+	}
+
+	dst := map[string]interface{}{"a.b": 0}
+	err := Options{Collisions: ErrorOnCollision}.FlattenInto(dst, nested, "", DotStyle)
+	var collision *CollisionError
+	if !errors.As(err, &collision) || collision.Key != "a.b" || collision.Paths[0] != "(already in the map)" {
+		t.Errorf("error mismatch, got: [%v], wanted a collision with the key already in the map", err)
+	}
+
+	dst = map[string]interface{}{"x": 1}
+	if err := FlattenInto(dst, nested, "n.", DotStyle); err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+	if want := map[string]interface{}{"x": 1, "n.a.b": 1, "n.c": 2}; !reflect.DeepEqual(dst, want) {
+		t.Errorf("mismatch, got: %v wanted: %v", dst, want)
+	}
+}
+
+func TestStrictSanitizeCollisions(t *testing.T) {
+	style := SeparatorStyle{Middle: "_", Sanitizer: IdentifierSanitizer}
+	opts := Options{StrictSanitize: true, Collisions: ErrorOnCollision}
+
+	got, err := opts.Flatten(map[string]interface{}{"db": map[string]interface{}{"host": "h", "port": 1}}, "", style)
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+	if want := map[string]interface{}{"db_host": "h", "db_port": 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %v wanted: %v", got, want)
+	}
+
+	sorted := func(paths []string) []string { // in map order, without a CollisionPolicy sorting them
+		paths = append([]string(nil), paths...)
+		sort.Strings(paths)
+		return paths
+	}
+
+	cases := []struct {
+		nested map[string]interface{}
+		paths  []string // sorted
+	}{
+		// 1 -- alike before sanitizing, so a collision of paths
+		{map[string]interface{}{"a_b": 1, "a": map[string]interface{}{"b": 2}}, []string{".a.b", ".a_b"}},
+		// 2 -- the sanitizer's doing
+		{map[string]interface{}{"a.b": 1, "a-b": 2}, []string{".\"a-b\"", ".\"a.b\""}},
+	}
+
+	for i, test := range cases {
+		_, err := opts.Flatten(test.nested, "", style)
+		var collision *CollisionError
+		if !errors.As(err, &collision) || collision.Key != "a_b" || !reflect.DeepEqual(sorted(collision.Paths), test.paths) {
+			t.Errorf("%d: error mismatch, got: [%v], wanted a collision at \"a_b\" from %v", i+1, err, test.paths)
+		}
+	}
+}
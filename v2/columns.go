@@ -0,0 +1,44 @@
+package flatten
+
+// FlattenColumns flattens a batch of documents into columns: one slice per flattened key, holding the
+// key's value in each document in turn, or nil where a document lacks it.  Every column has one entry
+// per document, ready for columnar consumers such as Arrow record batches or dataframes.  Keys are in
+// DotStyle, without a prefix.  A document which cannot be flattened, e.g. a cyclic one, is nil in every
+// column; Options.FlattenColumns reports it instead.
+func FlattenColumns(docs []map[string]interface{}) map[string][]interface{} {
+	columns, _ := settings{style: DotStyle}.flattenColumns("FlattenColumns", docs, true)
+	return columns
+}
+
+// FlattenColumns flattens a batch of documents into columns, as the package-level FlattenColumns does,
+// under the options, the prefix and the style, stopping at the first document which cannot be flattened.
+func (o Options) FlattenColumns(docs []map[string]interface{}, prefix string, style SeparatorStyle) (map[string][]interface{}, error) {
+	return settings{prefix: prefix, style: style, opts: o}.flattenColumns("Options.FlattenColumns", docs, false)
+}
+
+// flattenColumns flattens docs into columns under the settings, observed as op, leaving out the
+// documents which fail to flatten if skip, or else returning the first error.
+func (s settings) flattenColumns(op string, docs []map[string]interface{}, skip bool) (map[string][]interface{}, error) {
+	columns := make(map[string][]interface{})
+
+	for i, doc := range docs {
+		flat, err := s.flatten(op, doc)
+		if err != nil {
+			if skip {
+				continue
+			}
+			return nil, err
+		}
+
+		for k, v := range flat {
+			col, ok := columns[k]
+			if !ok {
+				col = make([]interface{}, len(docs))
+				columns[k] = col
+			}
+			col[i] = v
+		}
+	}
+
+	return columns, nil
+}
@@ -0,0 +1,60 @@
+package flatten
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestFlattenColumns(t *testing.T) {
+	docs := []map[string]interface{}{
+		{"a": map[string]interface{}{"b": 1.0}, "c": "x"},
+		{"c": "y", "d": []interface{}{true}},
+		{},
+	}
+
+	got := FlattenColumns(docs)
+	want := map[string][]interface{}{
+		"a.b": {1.0, nil, nil},
+		"c":   {"x", "y", nil},
+		"d.0": {nil, true, nil},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %v wanted: %v", got, want)
+	}
+}
+
+func TestFlattenColumnsEmpty(t *testing.T) {
+	if got := FlattenColumns(nil); len(got) != 0 {
+		t.Errorf("mismatch, got: %v wanted: empty", got)
+	}
+}
+
+func TestFlattenColumnsOptions(t *testing.T) {
+	cyclic := map[string]interface{}{"c": "z"}
+	cyclic["self"] = cyclic
+	docs := []map[string]interface{}{
+		{"a": map[string]interface{}{"b": 1.0}, "c": "x"},
+		cyclic,
+	}
+
+	got, err := Options{}.FlattenColumns(docs[:1], "p_", UnderscoreStyle)
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+	want := map[string][]interface{}{"p_a_b": {1.0}, "p_c": {"x"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %v wanted: %v", got, want)
+	}
+
+	if _, err := (Options{}).FlattenColumns(docs, "", DotStyle); !errors.Is(err, CyclicInputError) {
+		t.Errorf("error mismatch, got: [%v], wanted: [%v]", err, CyclicInputError)
+	}
+
+	// The package-level form leaves the cyclic document out
+	got = FlattenColumns(docs)
+	want = map[string][]interface{}{"a.b": {1.0, nil}, "c": {"x", nil}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("cyclic mismatch, got: %v wanted: %v", got, want)
+	}
+}
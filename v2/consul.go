@@ -0,0 +1,66 @@
+package flatten
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ConsulExport flattens nested into Consul KV entries, in key order, with slash-separated keys under
+// base, e.g. "config/app/db/port", and values rendered by encode, or by TextValue if encode is nil.
+// Slashes within original keys are percent-encoded.  Consul keys have no leading slash, so one on base
+// is dropped.
+func ConsulExport(base string, nested map[string]interface{}, encode ValueEncoder) ([]KeyValue, error) {
+	flat, err := Flatten(nested, withSlash(strings.TrimPrefix(base, "/")), etcdStyle)
+	if err != nil {
+		return nil, err
+	}
+
+	if encode == nil {
+		encode = TextValue
+	}
+
+	kvs := make([]KeyValue, 0, len(flat))
+	for _, k := range sortedKeys(flat) {
+		value, err := encode(flat[k])
+		if err != nil {
+			return nil, err
+		}
+		kvs = append(kvs, KeyValue{Key: k, Value: value})
+	}
+
+	return kvs, nil
+}
+
+// ConsulImport rebuilds a nested map from a Consul KV listing under base, e.g. the result of a
+// recursive get, reversing ConsulExport.  Values are parsed by decode, or kept as strings if decode is
+// nil.  As in Consul, a key ending in a slash is a folder: it becomes an empty map, unless other keys
+// fill it.  Entries outside base are ignored.
+func ConsulImport(base string, kvs []KeyValue, decode ValueDecoder) (map[string]interface{}, error) {
+	base = withSlash(strings.TrimPrefix(base, "/"))
+
+	if decode == nil {
+		decode = ParseTextValue
+	}
+
+	flat := make(map[string]interface{}, len(kvs))
+	for _, kv := range kvs {
+		key := strings.TrimPrefix(kv.Key, "/")
+		if !strings.HasPrefix(key, base) || len(key) == len(base) {
+			continue
+		}
+		key = key[len(base):]
+
+		if strings.HasSuffix(key, "/") {
+			flat[strings.TrimSuffix(key, "/")] = map[string]interface{}{}
+			continue
+		}
+
+		v, err := decode(kv.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", kv.Key, err)
+		}
+		flat[key] = v
+	}
+
+	return nest(flat, splitSlashKey)
+}
@@ -0,0 +1,98 @@
+package flatten
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestConsulExport(t *testing.T) {
+	nested := map[string]interface{}{
+		"db": map[string]interface{}{
+			"host": "localhost",
+			"port": 5432.0,
+		},
+		"flags": []interface{}{true},
+	}
+
+	cases := []struct {
+		encode ValueEncoder
+		want   []KeyValue
+	}{
+		// 1
+		{
+			nil,
+			[]KeyValue{
+				{"config/app/db/host", []byte("localhost")},
+				{"config/app/db/port", []byte("5432")},
+				{"config/app/flags/0", []byte("true")},
+			},
+		},
+		// 2
+		{
+			JSONValue,
+			[]KeyValue{
+				{"config/app/db/host", []byte(`"localhost"`)},
+				{"config/app/db/port", []byte("5432")},
+				{"config/app/flags/0", []byte("true")},
+			},
+		},
+	}
+
+	for i, test := range cases {
+		got, err := ConsulExport("/config/app", nested, test.encode)
+		if err != nil {
+			t.Errorf("%d: failed to export: %v", i+1, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%d: mismatch, got: %q wanted: %q", i+1, got, test.want)
+		}
+	}
+}
+
+func TestConsulImport(t *testing.T) {
+	kvs := []KeyValue{
+		{"config/app/", nil},
+		{"config/app/db/", nil},
+		{"config/app/db/host", []byte(`"localhost"`)},
+		{"config/app/db/port", []byte("5432")},
+		{"config/app/empty/", nil},
+		{"config/app/list/0", []byte(`"a"`)},
+		{"config/other/x", []byte("1")},
+	}
+
+	cases := []struct {
+		decode ValueDecoder
+		want   map[string]interface{}
+	}{
+		// 1
+		{
+			nil,
+			map[string]interface{}{
+				"db":    map[string]interface{}{"host": `"localhost"`, "port": "5432"},
+				"empty": map[string]interface{}{},
+				"list":  []interface{}{`"a"`},
+			},
+		},
+		// 2
+		{
+			ParseJSONValue,
+			map[string]interface{}{
+				"db":    map[string]interface{}{"host": "localhost", "port": 5432.0},
+				"empty": map[string]interface{}{},
+				"list":  []interface{}{"a"},
+			},
+		},
+	}
+
+	for i, test := range cases {
+		got, err := ConsulImport("config/app", kvs, test.decode)
+		if err != nil {
+			t.Errorf("%d: failed to import: %v", i+1, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%d: mismatch, got: %v wanted: %v", i+1, got, test.want)
+		}
+	}
+}
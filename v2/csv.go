@@ -0,0 +1,28 @@
+package flatten
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+// FlattenToCSV flattens nested and writes it to w as CSV: a header row of the flattened keys, sorted,
+// and a row of their values, as text.
+func FlattenToCSV(nested map[string]interface{}, style SeparatorStyle, w io.Writer) error {
+	flat, err := Flatten(nested, "", style)
+	if err != nil {
+		return err
+	}
+
+	keys := sortedKeys(flat)
+	values := make([]string, len(keys))
+	for i, k := range keys {
+		values[i] = formatValue(flat[k])
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Write(keys)
+	cw.Write(values)
+	cw.Flush()
+
+	return cw.Error()
+}
@@ -0,0 +1,43 @@
+package flatten
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFlattenToCSV(t *testing.T) {
+	cases := []struct {
+		nested map[string]interface{}
+		style  SeparatorStyle
+		want   string
+	}{
+		// 1
+		{
+			map[string]interface{}{
+				"b": map[string]interface{}{"c": 1.5, "d": []interface{}{true, nil}},
+				"a": "x",
+			},
+			DotStyle,
+			"a,b.c,b.d.0,b.d.1\nx,1.5,true,\n",
+		},
+		// 2 -- quoting
+		{
+			map[string]interface{}{"a,b": "say \"hi\"", "n": "two\nlines"},
+			PathStyle,
+			"\"a,b\",n\n\"say \"\"hi\"\"\",\"two\nlines\"\n",
+		},
+		// 3
+		{map[string]interface{}{}, DotStyle, "\n\n"},
+	}
+
+	for i, test := range cases {
+		var b strings.Builder
+		if err := FlattenToCSV(test.nested, test.style, &b); err != nil {
+			t.Errorf("%d: failed to write: %v", i+1, err)
+			continue
+		}
+		if got := b.String(); got != test.want {
+			t.Errorf("%d: mismatch, got: %q wanted: %q", i+1, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package flatten
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// The input holds itself, through a map, slice or pointer
+var CyclicInputError = errors.New("Not a valid input: value contains itself")
+
+// A CycleError reports where a value was met within itself.  It is a CyclicInputError, to errors.Is.
+type CycleError struct {
+	Key string // The flat key at which the cycle closes
+}
+
+func (e *CycleError) Error() string {
+	return fmt.Sprintf("%v: at %q", CyclicInputError, e.Key)
+}
+
+func (e *CycleError) Unwrap() error {
+	return CyclicInputError
+}
+
+// A CyclePolicy says what flattening does on meeting a map, slice or pointer within itself, as with a
+// struct whose Parent field leads back to it, which would otherwise recurse forever.
+type CyclePolicy int
+
+const (
+	ErrorOnCycle CyclePolicy = iota // Fail with a *CycleError
+	CutCycles                       // Keep the key at which the cycle closes, with a nil value
+)
+
+// A ref identifies a map, slice or pointer, by its type and address, and for slices its length, as a
+// struct and its first field share an address.
+type ref struct {
+	typ reflect.Type
+	ptr uintptr
+	len int
+}
+
+// refOf returns the ref of v, or false if v is not a non-nil map, slice or pointer.
+func refOf(v interface{}) (ref, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Map, reflect.Ptr:
+		if !rv.IsNil() {
+			return ref{typ: rv.Type(), ptr: rv.Pointer()}, true
+		}
+	case reflect.Slice:
+		if rv.Len() > 0 {
+			return ref{typ: rv.Type(), ptr: rv.Pointer(), len: rv.Len()}, true
+		}
+	}
+	return ref{}, false
+}
+
+// enter notes v, before its children are flattened, returning false if it is already being flattened,
+// further up the path.  leave must follow a successful enter.
+func (f *flattener) enter(v interface{}) (r ref, ok bool) {
+	r, tracked := refOf(v)
+	if !tracked {
+		return r, true
+	}
+	if f.visiting[r] {
+		return r, false
+	}
+	if f.visiting == nil {
+		f.visiting = make(map[ref]bool)
+	}
+	f.visiting[r] = true
+	return r, true
+}
+
+func (f *flattener) leave(r ref) {
+	delete(f.visiting, r)
+}
@@ -0,0 +1,83 @@
+package flatten
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type node struct {
+	Name   string
+	Parent *node
+}
+
+func TestCycles(t *testing.T) {
+	loop := map[string]interface{}{"a": 1}
+	loop["self"] = loop
+
+	list := []interface{}{"x", nil}
+	list[1] = list
+
+	root := &node{Name: "root"}
+	root.Parent = &node{Name: "child", Parent: root}
+
+	shared := map[string]interface{}{"k": "v"}
+
+	cases := []struct {
+		flatten func(o Options) (map[string]interface{}, error)
+		key     string // Where the cycle closes, if one does
+		cut     map[string]interface{}
+	}{
+		// 1
+		{
+			func(o Options) (map[string]interface{}, error) { return o.Flatten(loop, "", DotStyle) },
+			"self",
+			map[string]interface{}{"a": 1, "self": nil},
+		},
+		// 2
+		{
+			func(o Options) (map[string]interface{}, error) {
+				return o.Flatten(map[string]interface{}{"l": list}, "", DotStyle)
+			},
+			"l.1",
+			map[string]interface{}{"l.0": "x", "l.1": nil},
+		},
+		// 3
+		{
+			func(o Options) (map[string]interface{}, error) { return o.FlattenValue(root, "", DotStyle) },
+			"Parent.Parent",
+			map[string]interface{}{"Name": "root", "Parent.Name": "child", "Parent.Parent": nil},
+		},
+		// 4 -- values met twice, but not within themselves, are not cycles
+		{
+			func(o Options) (map[string]interface{}, error) {
+				return o.Flatten(map[string]interface{}{"x": shared, "y": []interface{}{shared}}, "", DotStyle)
+			},
+			"",
+			map[string]interface{}{"x.k": "v", "y.0.k": "v"},
+		},
+	}
+
+	for i, test := range cases {
+		got, err := test.flatten(Options{})
+		if test.key == "" {
+			if err != nil || !reflect.DeepEqual(got, test.cut) {
+				t.Errorf("%d: mismatch, got: %v, %v wanted: %v", i+1, got, err, test.cut)
+			}
+			continue
+		}
+		var cycle *CycleError
+		if !errors.As(err, &cycle) || cycle.Key != test.key || !errors.Is(err, CyclicInputError) {
+			t.Errorf("%d: error mismatch, got: [%v], wanted a cycle at %q", i+1, err, test.key)
+		}
+
+		got, err = test.flatten(Options{Cycles: CutCycles})
+		if err != nil {
+			t.Errorf("%d: failed to flatten: %v", i+1, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.cut) {
+			t.Errorf("%d: mismatch, got: %v wanted: %v", i+1, got, test.cut)
+		}
+	}
+}
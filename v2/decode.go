@@ -0,0 +1,295 @@
+package flatten
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The destination of a decode must be a non-nil pointer
+var NotValidDestError = errors.New("Not a valid destination: must be a non-nil pointer")
+
+// A value cannot be decoded into its destination
+var CannotDecodeError = errors.New("Not a valid input: cannot decode value")
+
+// UnflattenInto builds a nested map from a flat one, as Unflatten does, and decodes it into dest, a
+// pointer to a struct, map or slice, e.g. a config struct from environment variables.
+//
+// Struct fields are matched by their json tag name (or that of Options.TagName), or else their own
+// name, ignoring case; a "-" tag skips them.  Strings are parsed for numbers, booleans and durations,
+// and given to UnmarshalText where a type has it, since flat sources tend to hold text.  Unmatched keys
+// are ignored.
+func UnflattenInto(flat map[string]interface{}, style SeparatorStyle, dest interface{}) error {
+	return Options{}.UnflattenInto(flat, style, dest)
+}
+
+// UnflattenInto builds a nested map from a flat one, as Options.Unflatten does, and decodes it into
+// dest, as the package-level UnflattenInto does.
+func (o Options) UnflattenInto(flat map[string]interface{}, style SeparatorStyle, dest interface{}) error {
+	rv := reflect.ValueOf(dest)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return NotValidDestError
+	}
+
+	nested, err := o.Unflatten(flat, style)
+	if err != nil {
+		return err
+	}
+
+	return decode(nested, rv.Elem(), nil, o.tagName())
+}
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// decode sets dst from v, a nested value found at path.
+func decode(v interface{}, dst reflect.Value, path []interface{}, tag string) error {
+	fail := func() error {
+		return fmt.Errorf("%w: %T at %s into %v", CannotDecodeError, v, pathString(path), dst.Type())
+	}
+
+	if v == nil {
+		return nil
+	}
+
+	if dst.Kind() == reflect.Ptr {
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+		return decode(v, dst.Elem(), path, tag)
+	}
+
+	if s, ok := v.(string); ok && dst.CanAddr() && dst.Addr().Type().Implements(textUnmarshalerType) {
+		if err := dst.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)); err != nil {
+			return fmt.Errorf("%w: at %s: %v", CannotDecodeError, pathString(path), err)
+		}
+		return nil
+	}
+
+	if dst.Type() == durationType {
+		if s, ok := v.(string); ok {
+			d, err := time.ParseDuration(s)
+			if err != nil {
+				return fail()
+			}
+			dst.SetInt(int64(d))
+			return nil
+		}
+	}
+
+	switch dst.Kind() {
+	case reflect.Interface:
+		if dst.NumMethod() != 0 {
+			return fail()
+		}
+		dst.Set(reflect.ValueOf(v))
+
+	case reflect.Struct:
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return fail()
+		}
+		return decodeStruct(m, dst, path, tag)
+
+	case reflect.Map:
+		m, ok := v.(map[string]interface{})
+		if !ok || dst.Type().Key().Kind() != reflect.String {
+			return fail()
+		}
+		if dst.IsNil() {
+			dst.Set(reflect.MakeMapWithSize(dst.Type(), len(m)))
+		}
+		for k, child := range m {
+			elem := reflect.New(dst.Type().Elem()).Elem()
+			if err := decode(child, elem, append(path, k), tag); err != nil {
+				return err
+			}
+			dst.SetMapIndex(reflect.ValueOf(k).Convert(dst.Type().Key()), elem)
+		}
+
+	case reflect.Slice, reflect.Array:
+		list, ok := v.([]interface{})
+		if !ok {
+			return fail()
+		}
+		if dst.Kind() == reflect.Slice {
+			dst.Set(reflect.MakeSlice(dst.Type(), len(list), len(list)))
+		} else if len(list) > dst.Len() {
+			return fail()
+		}
+		for i, child := range list {
+			if err := decode(child, dst.Index(i), append(path, i), tag); err != nil {
+				return err
+			}
+		}
+
+	case reflect.String:
+		switch v := v.(type) {
+		case string:
+			dst.SetString(v)
+		case float64, int, bool:
+			dst.SetString(formatValue(v))
+		default:
+			return fail()
+		}
+
+	case reflect.Bool:
+		switch v := v.(type) {
+		case bool:
+			dst.SetBool(v)
+		case string:
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return fail()
+			}
+			dst.SetBool(b)
+		default:
+			return fail()
+		}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var n int64
+		switch v := v.(type) {
+		case float64:
+			if v != math.Trunc(v) {
+				return fail()
+			}
+			n = int64(v)
+		case int:
+			n = int64(v)
+		case string:
+			var err error
+			if n, err = strconv.ParseInt(v, 10, 64); err != nil {
+				return fail()
+			}
+		default:
+			return fail()
+		}
+		if dst.OverflowInt(n) {
+			return fail()
+		}
+		dst.SetInt(n)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var n uint64
+		switch v := v.(type) {
+		case float64:
+			if v < 0 || v != math.Trunc(v) {
+				return fail()
+			}
+			n = uint64(v)
+		case int:
+			if v < 0 {
+				return fail()
+			}
+			n = uint64(v)
+		case string:
+			var err error
+			if n, err = strconv.ParseUint(v, 10, 64); err != nil {
+				return fail()
+			}
+		default:
+			return fail()
+		}
+		if dst.OverflowUint(n) {
+			return fail()
+		}
+		dst.SetUint(n)
+
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		switch v := v.(type) {
+		case float64:
+			f = v
+		case int:
+			f = float64(v)
+		case string:
+			var err error
+			if f, err = strconv.ParseFloat(v, 64); err != nil {
+				return fail()
+			}
+		default:
+			return fail()
+		}
+		dst.SetFloat(f)
+
+	default:
+		return fail()
+	}
+
+	return nil
+}
+
+// decodeStruct sets the fields of dst from m, including those of embedded structs, named by tag.
+func decodeStruct(m map[string]interface{}, dst reflect.Value, path []interface{}, tag string) error {
+	t := dst.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue // unexported
+		}
+
+		name := field.Name
+		if tv, ok := field.Tag.Lookup(tag); ok {
+			tagName := strings.Split(tv, ",")[0]
+			if tagName == "-" {
+				continue
+			}
+			if tagName != "" {
+				name = tagName
+			}
+		}
+
+		if field.Anonymous && name == field.Name {
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				fv := dst.Field(i)
+				if fv.Kind() == reflect.Ptr {
+					if !fv.CanSet() {
+						continue
+					}
+					if fv.IsNil() {
+						fv.Set(reflect.New(ft))
+					}
+					fv = fv.Elem()
+				}
+				if err := decodeStruct(m, fv, path, tag); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+
+		key, ok := name, false
+		if _, ok = m[name]; !ok {
+			for k := range m {
+				if strings.EqualFold(k, name) {
+					key, ok = k, true
+					break
+				}
+			}
+		}
+		if !ok {
+			continue
+		}
+
+		if err := decode(m[key], dst.Field(i), append(path, key), tag); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
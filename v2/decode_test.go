@@ -0,0 +1,123 @@
+package flatten
+
+import (
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type dbConfig struct {
+	Host    string
+	Port    int
+	Timeout time.Duration
+}
+
+type Common struct {
+	Name string
+}
+
+type appConfig struct {
+	Common
+	DB      dbConfig          `json:"db"`
+	Replica *dbConfig         `json:"replica"`
+	Debug   bool              `json:"debug,omitempty"`
+	Ratio   float64           `json:"ratio"`
+	Tags    []string          `json:"tags"`
+	Labels  map[string]string `json:"labels"`
+	Addr    net.IP            `json:"addr"`
+	Skipped string            `json:"-"`
+	Extra   interface{}       `json:"extra"`
+	secret  string
+}
+
+func TestUnflattenInto(t *testing.T) {
+	flat := map[string]interface{}{
+		"name":             "web",
+		"db_host":          "db.local",
+		"db_port":          "5432",
+		"db_timeout":       "1m30s",
+		"replica_host":     "replica.local",
+		"replica_port":     5433.0,
+		"debug":            "true",
+		"ratio":            "0.25",
+		"tags_0":           "a",
+		"tags_1":           "b",
+		"labels_tier":      "front",
+		"addr":             "10.0.0.1",
+		"skipped":          "no",
+		"extra_k":          "v",
+		"secret":           "no",
+		"unmatched_anyway": 1.0,
+	}
+
+	var got appConfig
+	if err := UnflattenInto(flat, UnderscoreStyle, &got); err != nil {
+		t.Fatalf("failed to unflatten: %v", err)
+	}
+	want := appConfig{
+		Common:  Common{Name: "web"},
+		DB:      dbConfig{Host: "db.local", Port: 5432, Timeout: 90 * time.Second},
+		Replica: &dbConfig{Host: "replica.local", Port: 5433},
+		Debug:   true,
+		Ratio:   0.25,
+		Tags:    []string{"a", "b"},
+		Labels:  map[string]string{"tier": "front"},
+		Addr:    net.ParseIP("10.0.0.1"),
+		Extra:   map[string]interface{}{"k": "v"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %+v wanted: %+v", got, want)
+	}
+}
+
+func TestUnflattenIntoErrors(t *testing.T) {
+	cases := []struct {
+		flat map[string]interface{}
+		dest interface{}
+		err  error
+	}{
+		// 1
+		{map[string]interface{}{"port": "x"}, &dbConfig{}, CannotDecodeError},
+		// 2
+		{map[string]interface{}{"port": 1.5}, &dbConfig{}, CannotDecodeError},
+		// 3
+		{map[string]interface{}{"host_a": "x"}, &dbConfig{}, CannotDecodeError},
+		// 4
+		{map[string]interface{}{"port": "1"}, dbConfig{}, NotValidDestError},
+		// 5
+		{map[string]interface{}{"a": 300.0}, &map[string]int8{}, CannotDecodeError},
+	}
+
+	for i, test := range cases {
+		err := UnflattenInto(test.flat, UnderscoreStyle, test.dest)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%d: error mismatch, got: [%v], wanted: [%v]", i+1, err, test.err)
+		}
+	}
+}
+
+func TestUnflattenIntoCoerced(t *testing.T) {
+	var got dbConfig
+	err := Options{CoerceValues: true}.UnflattenInto(map[string]interface{}{"host": "10", "port": "5432"}, DotStyle, &got)
+	if err != nil {
+		t.Fatalf("failed to unflatten: %v", err)
+	}
+	if want := (dbConfig{Host: "10", Port: 5432}); got != want {
+		t.Errorf("mismatch, got: %+v wanted: %+v", got, want)
+	}
+}
+
+func TestUnflattenIntoTagName(t *testing.T) {
+	var got viperConfig
+	flat := map[string]interface{}{"pool_size": "8", "hosts.0": "a", "poolSize": "99", "debug": "true"}
+	if err := (Options{TagName: "mapstructure"}).UnflattenInto(flat, DotStyle, &got); err != nil {
+		t.Fatalf("failed to decode: %v", err)
+	}
+
+	want := viperConfig{PoolSize: 8, Hosts: []string{"a"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %+v wanted: %+v", got, want)
+	}
+}
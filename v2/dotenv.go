@@ -0,0 +1,58 @@
+package flatten
+
+import (
+	"strings"
+)
+
+// FlattenToEnv flattens nested into the lines of a .env file, sorted, e.g. with prefix "APP_"
+//
+//	APP_DB_HOST=localhost
+//	APP_DB_POOL_SIZE=10
+//	APP_GREETING="hello, world"
+//
+// Names are the words of each key segment, uppercased and joined by underscores, so "poolSize" and
+// "pool-size" alike become POOL_SIZE; the prefix is prepended as it is.  Values holding anything but
+// letters, digits and _ . / : @ % + , - are double-quoted, escaping backslashes, quotes, dollar signs
+// and newlines.  NestedFromEnv reads such variables back, named in lowercase.
+func FlattenToEnv(nested map[string]interface{}, prefix string) (string, error) {
+	flat, err := Flatten(nested, prefix, envStyle)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for _, k := range sortedKeys(flat) {
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(envQuote(formatValue(flat[k])))
+		b.WriteByte('\n')
+	}
+
+	return b.String(), nil
+}
+
+// Separate with underscores, uppercasing words, e.g. "DB_POOL_SIZE"
+var envStyle = SeparatorStyle{Middle: "_", Sanitizer: envName{}}
+
+// envName uppercases the words of a segment and joins them with underscores.
+type envName struct{}
+
+func (envName) SanitizeKey(segment string) string {
+	return strings.ToUpper(strings.Join(words(segment), "_"))
+}
+
+func envQuote(s string) string {
+	plain := true
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.IndexByte("_./:@%+,-", c) >= 0) {
+			plain = false
+			break
+		}
+	}
+	if plain {
+		return s
+	}
+
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`, "\n", `\n`).Replace(s) + `"`
+}
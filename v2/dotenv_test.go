@@ -0,0 +1,41 @@
+package flatten
+
+import (
+	"testing"
+)
+
+func TestFlattenToEnv(t *testing.T) {
+	cases := []struct {
+		nested map[string]interface{}
+		prefix string
+		want   string
+	}{
+		// 1
+		{
+			map[string]interface{}{
+				"db":       map[string]interface{}{"host": "localhost", "poolSize": 10.0, "replicas": []interface{}{"a:5432"}},
+				"greeting": "hello, world",
+				"debug":    false,
+			},
+			"APP_",
+			"APP_DB_HOST=localhost\nAPP_DB_POOL_SIZE=10\nAPP_DB_REPLICAS_0=a:5432\nAPP_DEBUG=false\nAPP_GREETING=\"hello, world\"\n",
+		},
+		// 2 -- quoting
+		{
+			map[string]interface{}{"my-key": "say \"$HOME\"\\\nbye", "empty": nil},
+			"",
+			"EMPTY=\nMY_KEY=\"say \\\"\\$HOME\\\"\\\\\\nbye\"\n",
+		},
+	}
+
+	for i, test := range cases {
+		got, err := FlattenToEnv(test.nested, test.prefix)
+		if err != nil {
+			t.Errorf("%d: failed to flatten: %v", i+1, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%d: mismatch, got: %q wanted: %q", i+1, got, test.want)
+		}
+	}
+}
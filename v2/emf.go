@@ -0,0 +1,55 @@
+package flatten
+
+import (
+	"time"
+)
+
+// CloudWatch's limit on dimensions in one dimension set of an Embedded Metric Format document.
+const EMFMaxDimensions = 30
+
+// EMF flattens a metrics payload with DotStyle into a CloudWatch Embedded Metric Format document, ready
+// to marshal as one log line.  Numeric leaves become metrics and string leaves dimensions, which form
+// the document's one dimension set (first in key order, at most EMFMaxDimensions; the rest are kept
+// as plain properties).  Other leaves are kept as plain properties.  The report lists dimensions left
+// out of the set.
+func EMF(namespace string, payload map[string]interface{}, timestamp time.Time) (map[string]interface{}, LimitReport, error) {
+	var report LimitReport
+
+	flat, err := Flatten(payload, "", DotStyle)
+	if err != nil {
+		return nil, report, err
+	}
+
+	doc := make(map[string]interface{}, len(flat)+1)
+	metrics := []interface{}{}
+	dimensions := []string{}
+
+	for _, k := range sortedKeys(flat) {
+		v := flat[k]
+		doc[k] = v
+
+		switch v.(type) {
+		case float64, float32, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+			metrics = append(metrics, map[string]interface{}{"Name": k})
+		case string:
+			if len(dimensions) == EMFMaxDimensions {
+				report.Dropped = append(report.Dropped, k)
+				continue
+			}
+			dimensions = append(dimensions, k)
+		}
+	}
+
+	doc["_aws"] = map[string]interface{}{
+		"Timestamp": timestamp.UnixNano() / int64(time.Millisecond),
+		"CloudWatchMetrics": []interface{}{
+			map[string]interface{}{
+				"Namespace":  namespace,
+				"Dimensions": []interface{}{dimensions},
+				"Metrics":    metrics,
+			},
+		},
+	}
+
+	return doc, report, nil
+}
@@ -0,0 +1,55 @@
+package flatten
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEMF(t *testing.T) {
+	payload := map[string]interface{}{
+		"service": "api",
+		"http": map[string]interface{}{
+			"latency": 12.5,
+			"status":  200.0,
+			"route":   "/users",
+		},
+		"cached": true,
+	}
+
+	doc, report, err := EMF("MyApp", payload, time.Unix(1600000000, 0))
+	if err != nil {
+		t.Fatalf("failed to build: %v", err)
+	}
+
+	got, _ := json.Marshal(doc)
+	want := `{"_aws":{"CloudWatchMetrics":[{"Dimensions":[["http.route","service"]],"Metrics":[{"Name":"http.latency"},{"Name":"http.status"}],"Namespace":"MyApp"}],"Timestamp":1600000000000},` +
+		`"cached":true,"http.latency":12.5,"http.route":"/users","http.status":200,"service":"api"}`
+	if string(got) != want {
+		t.Errorf("mismatch, got: %s wanted: %s", got, want)
+	}
+	if len(report.Dropped) != 0 {
+		t.Errorf("report mismatch, got: %v wanted: nothing dropped", report)
+	}
+}
+
+func TestEMFMaxDimensions(t *testing.T) {
+	payload := make(map[string]interface{})
+	for i := 0; i < EMFMaxDimensions+1; i++ {
+		payload[fmt.Sprintf("d%02d", i)] = "v"
+	}
+
+	doc, report, err := EMF("MyApp", payload, time.Unix(0, 0))
+	if err != nil {
+		t.Fatalf("failed to build: %v", err)
+	}
+
+	if want := []string{fmt.Sprintf("d%02d", EMFMaxDimensions)}; !reflect.DeepEqual(report.Dropped, want) {
+		t.Errorf("report mismatch, got: %v wanted: %v", report.Dropped, want)
+	}
+	if _, ok := doc[fmt.Sprintf("d%02d", EMFMaxDimensions)]; !ok {
+		t.Errorf("dropped dimension should remain a property")
+	}
+}
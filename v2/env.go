@@ -0,0 +1,25 @@
+package flatten
+
+import (
+	"os"
+	"strings"
+)
+
+// NestedFromEnv builds a nested map from the environment variables whose names start with prefix, e.g.
+// with prefix "APP_" and UnderscoreStyle, APP_DB_HOST=x and APP_DB_PORTS_0=5432 become
+//
+//	{ "db": { "host": "x", "ports": [ "5432" ] } }
+//
+// Names are lowercased, then split with style after the prefix.  Values are strings.
+func NestedFromEnv(prefix string, style SeparatorStyle) (map[string]interface{}, error) {
+	flat := make(map[string]interface{})
+	for _, kv := range os.Environ() {
+		eq := strings.IndexByte(kv, '=')
+		if eq < 0 || !strings.HasPrefix(kv[:eq], prefix) || eq == len(prefix) {
+			continue
+		}
+		flat[strings.ToLower(kv[len(prefix):eq])] = kv[eq+1:]
+	}
+
+	return nest(flat, style.SplitKey)
+}
@@ -0,0 +1,40 @@
+package flatten
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestNestedFromEnv(t *testing.T) {
+	t.Setenv("FLATTEN_TEST_DB_HOST", "db.local")
+	t.Setenv("FLATTEN_TEST_DB_PORTS_0", "5432")
+	t.Setenv("FLATTEN_TEST_DB_PORTS_1", "5433")
+	t.Setenv("FLATTEN_TEST_DEBUG", "")
+	t.Setenv("FLATTEN_TESTING", "not selected")
+
+	got, err := NestedFromEnv("FLATTEN_TEST_", UnderscoreStyle)
+	if err != nil {
+		t.Fatalf("failed to nest: %v", err)
+	}
+	want := map[string]interface{}{
+		"db": map[string]interface{}{
+			"host":  "db.local",
+			"ports": []interface{}{"5432", "5433"},
+		},
+		"debug": "",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %v wanted: %v", got, want)
+	}
+}
+
+func TestNestedFromEnvConflict(t *testing.T) {
+	t.Setenv("FLATTEN_TEST_A", "x")
+	t.Setenv("FLATTEN_TEST_A_B", "y")
+
+	_, err := NestedFromEnv("FLATTEN_TEST_", UnderscoreStyle)
+	if !errors.Is(err, LeafParentConflictError) {
+		t.Errorf("error mismatch, got: [%v], wanted: [%v]", err, LeafParentConflictError)
+	}
+}
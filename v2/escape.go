@@ -0,0 +1,232 @@
+package flatten
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// An Escaper encodes characters in a single key segment that would otherwise be mistaken for a style's
+// separators, e.g. a literal "]" within a key under RailsStyle.  Unescape reverses Escape.
+type Escaper interface {
+	Escape(segment string) string
+	Unescape(segment string) string
+}
+
+// PercentEscaper percent-encodes each of its (ASCII) characters, and '%' itself, wherever they occur in a
+// segment.  For example, PercentEscaper("[]") escapes "b[0]" as "b%5B0%5D".
+type PercentEscaper string
+
+const upperhex = "0123456789ABCDEF"
+
+func (e PercentEscaper) Escape(segment string) string {
+	if !strings.ContainsAny(segment, string(e)+"%") {
+		return segment
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(segment); i++ {
+		c := segment[i]
+		if c == '%' || strings.IndexByte(string(e), c) >= 0 {
+			b.WriteByte('%')
+			b.WriteByte(upperhex[c>>4])
+			b.WriteByte(upperhex[c&15])
+		} else {
+			b.WriteByte(c)
+		}
+	}
+
+	return b.String()
+}
+
+func (e PercentEscaper) Unescape(segment string) string {
+	if !strings.Contains(segment, "%") {
+		return segment
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(segment); i++ {
+		if segment[i] == '%' && i+2 < len(segment) {
+			hi, lo := unhex(segment[i+1]), unhex(segment[i+2])
+			if hi >= 0 && lo >= 0 {
+				c := byte(hi<<4 | lo)
+				if c == '%' || strings.IndexByte(string(e), c) >= 0 {
+					b.WriteByte(c)
+					i += 2
+					continue
+				}
+			}
+		}
+		b.WriteByte(segment[i])
+	}
+
+	return b.String()
+}
+
+func unhex(c byte) int {
+	switch {
+	case '0' <= c && c <= '9':
+		return int(c - '0')
+	case 'a' <= c && c <= 'f':
+		return int(c - 'a' + 10)
+	case 'A' <= c && c <= 'F':
+		return int(c - 'A' + 10)
+	}
+	return -1
+}
+
+// BackslashEscaper precedes each of its characters, and the backslash itself, with a backslash wherever
+// they occur in a segment.  For example, BackslashEscaper(".") escapes "k8s.io" as `k8s\.io`.
+type BackslashEscaper string
+
+func (e BackslashEscaper) Escape(segment string) string {
+	if !strings.ContainsAny(segment, string(e)+"\\") {
+		return segment
+	}
+
+	var b strings.Builder
+	for _, r := range segment {
+		if r == '\\' || strings.ContainsRune(string(e), r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+func (e BackslashEscaper) Unescape(segment string) string {
+	if !strings.Contains(segment, "\\") {
+		return segment
+	}
+
+	var b strings.Builder
+	escaped := false
+	for _, r := range segment {
+		if r == '\\' && !escaped {
+			escaped = true
+			continue
+		}
+		escaped = false
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+// A MarkEscaper precedes each of its Chars, and its Mark itself, with the Mark wherever they occur in a
+// segment, as BackslashEscaper does with a backslash.  For example, MarkEscaper{Mark: "~", Chars: "."}
+// escapes "k8s.io" as "k8s~.io", and "a~b" as "a~~b".
+type MarkEscaper struct {
+	Mark  string
+	Chars string
+}
+
+func (e MarkEscaper) Escape(segment string) string {
+	if e.Mark == "" || !strings.ContainsAny(segment, e.Chars) && !strings.Contains(segment, e.Mark) {
+		return segment
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(segment); {
+		if strings.HasPrefix(segment[i:], e.Mark) {
+			b.WriteString(e.Mark + e.Mark)
+			i += len(e.Mark)
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(segment[i:])
+		if strings.ContainsRune(e.Chars, r) {
+			b.WriteString(e.Mark)
+		}
+		b.WriteString(segment[i : i+size])
+		i += size
+	}
+
+	return b.String()
+}
+
+func (e MarkEscaper) Unescape(segment string) string {
+	if e.Mark == "" || !strings.Contains(segment, e.Mark) {
+		return segment
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(segment); {
+		n := 0
+		if strings.HasPrefix(segment[i:], e.Mark) {
+			i += len(e.Mark)
+			n = escapedLen(segment[i:], e.Mark)
+		} else {
+			_, n = utf8.DecodeRuneInString(segment[i:])
+		}
+		b.WriteString(segment[i : i+n])
+		i += n
+	}
+
+	return b.String()
+}
+
+// escapedLen returns the length of what a mark escapes at the start of s: the mark again, or a rune.
+func escapedLen(s, mark string) int {
+	if strings.HasPrefix(s, mark) {
+		return len(mark)
+	}
+	_, size := utf8.DecodeRuneInString(s)
+	return size
+}
+
+// escapeMark returns the mark with which e precedes what it escapes, if it is a BackslashEscaper or
+// MarkEscaper, so separators it escapes can be told from real ones.
+func escapeMark(e Escaper) string {
+	switch e := e.(type) {
+	case BackslashEscaper:
+		return "\\"
+	case MarkEscaper:
+		return e.Mark
+	}
+	return ""
+}
+
+// URIEscaper percent-encodes each byte of a segment outside the unreserved characters of RFC 3986 (letters,
+// digits, '-', '.', '_' and '~'), so keys can serve as URL paths or object store keys, e.g. "a b/c" becomes
+// "a%20b%2Fc".
+type URIEscaper struct{}
+
+func (URIEscaper) Escape(segment string) string {
+	var b strings.Builder
+	for i := 0; i < len(segment); i++ {
+		c := segment[i]
+		if isUnreserved(c) {
+			b.WriteByte(c)
+		} else {
+			b.WriteByte('%')
+			b.WriteByte(upperhex[c>>4])
+			b.WriteByte(upperhex[c&15])
+		}
+	}
+	return b.String()
+}
+
+func (URIEscaper) Unescape(segment string) string {
+	if !strings.Contains(segment, "%") {
+		return segment
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(segment); i++ {
+		if segment[i] == '%' && i+2 < len(segment) {
+			hi, lo := unhex(segment[i+1]), unhex(segment[i+2])
+			if hi >= 0 && lo >= 0 {
+				b.WriteByte(byte(hi<<4 | lo))
+				i += 2
+				continue
+			}
+		}
+		b.WriteByte(segment[i])
+	}
+
+	return b.String()
+}
+
+func isUnreserved(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-' || c == '.' || c == '_' || c == '~'
+}
@@ -0,0 +1,124 @@
+package flatten
+
+import (
+	"testing"
+)
+
+func TestPercentEscaper(t *testing.T) {
+	cases := []struct {
+		escaper PercentEscaper
+		segment string
+		want    string
+	}{
+		// 1
+		{PercentEscaper("[]"), "plain", "plain"},
+		// 2
+		{PercentEscaper("[]"), "b[0]", "b%5B0%5D"},
+		// 3 -- the escape character itself
+		{PercentEscaper("[]"), "100%]", "100%25%5D"},
+		// 4 -- already percent-encoded text survives a round trip
+		{PercentEscaper("[]"), "a%5Bb", "a%255Bb"},
+		// 5
+		{PercentEscaper("./"), "x.y/z", "x%2Ey%2Fz"},
+	}
+
+	for i, test := range cases {
+		got := test.escaper.Escape(test.segment)
+		if got != test.want {
+			t.Errorf("%d: escape mismatch, got: %v wanted: %v", i+1, got, test.want)
+		}
+		if back := test.escaper.Unescape(got); back != test.segment {
+			t.Errorf("%d: unescape mismatch, got: %v wanted: %v", i+1, back, test.segment)
+		}
+	}
+}
+
+func TestPercentUnescapeLeavesForeignSequences(t *testing.T) {
+	for i, s := range []string{"%41", "%", "%5", "%zz", "a%2"} {
+		if got := PercentEscaper("[]").Unescape(s); got != s {
+			t.Errorf("%d: mismatch, got: %v wanted: %v", i+1, got, s)
+		}
+	}
+}
+
+func TestBackslashEscaper(t *testing.T) {
+	cases := []struct {
+		escaper BackslashEscaper
+		segment string
+		want    string
+	}{
+		// 1
+		{BackslashEscaper("."), "plain", "plain"},
+		// 2
+		{BackslashEscaper("."), "k8s.io", `k8s\.io`},
+		// 3 -- the escape character itself
+		{BackslashEscaper("."), `C:\dir.d`, `C:\\dir\.d`},
+		// 4
+		{BackslashEscaper(".[]"), "a[0].b", `a\[0\]\.b`},
+	}
+
+	for i, test := range cases {
+		got := test.escaper.Escape(test.segment)
+		if got != test.want {
+			t.Errorf("%d: escape mismatch, got: %v wanted: %v", i+1, got, test.want)
+		}
+		if back := test.escaper.Unescape(got); back != test.segment {
+			t.Errorf("%d: unescape mismatch, got: %v wanted: %v", i+1, back, test.segment)
+		}
+	}
+}
+
+func TestMarkEscaper(t *testing.T) {
+	cases := []struct {
+		escaper MarkEscaper
+		segment string
+		want    string
+	}{
+		// 1
+		{MarkEscaper{"~", "."}, "plain", "plain"},
+		// 2
+		{MarkEscaper{"~", "."}, "k8s.io", "k8s~.io"},
+		// 3 -- the mark itself
+		{MarkEscaper{"~", "."}, "a~b.c", "a~~b~.c"},
+		// 4
+		{MarkEscaper{"%%", "./"}, "a/b.%%", "a%%/b%%.%%%%"},
+		// 5
+		{MarkEscaper{`\`, "é"}, "café", `caf\é`},
+	}
+
+	for i, test := range cases {
+		got := test.escaper.Escape(test.segment)
+		if got != test.want {
+			t.Errorf("%d: escape mismatch, got: %v wanted: %v", i+1, got, test.want)
+		}
+		if back := test.escaper.Unescape(got); back != test.segment {
+			t.Errorf("%d: unescape mismatch, got: %v wanted: %v", i+1, back, test.segment)
+		}
+	}
+}
+
+func TestURIEscaper(t *testing.T) {
+	cases := []struct {
+		segment string
+		want    string
+	}{
+		// 1
+		{"plain-key_1.2~x", "plain-key_1.2~x"},
+		// 2
+		{"a b/c", "a%20b%2Fc"},
+		// 3
+		{"50%?#[]", "50%25%3F%23%5B%5D"},
+		// 4 -- each byte of a multibyte rune
+		{"é", "%C3%A9"},
+	}
+
+	for i, test := range cases {
+		got := URIEscaper{}.Escape(test.segment)
+		if got != test.want {
+			t.Errorf("%d: escape mismatch, got: %v wanted: %v", i+1, got, test.want)
+		}
+		if back := (URIEscaper{}).Unescape(got); back != test.segment {
+			t.Errorf("%d: unescape mismatch, got: %v wanted: %v", i+1, back, test.segment)
+		}
+	}
+}
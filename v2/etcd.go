@@ -0,0 +1,75 @@
+package flatten
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A KeyValue is an entry of a key/value store.
+type KeyValue struct {
+	Key   string
+	Value []byte
+}
+
+// etcdStyle separates keys with slashes, as PathStyle, but percent-encodes slashes within keys so
+// that EtcdImport can take them apart again.
+var etcdStyle = SeparatorStyle{Middle: "/", Escaper: PercentEscaper("/")}
+
+// EtcdExport flattens nested into etcd entries, in key order, with slash-separated keys under base and
+// JSON-encoded values, e.g. "/config/app/db/port" = "5432".  Slashes within original keys are
+// percent-encoded.
+func EtcdExport(base string, nested map[string]interface{}) ([]KeyValue, error) {
+	flat, err := Flatten(nested, withSlash(base), etcdStyle)
+	if err != nil {
+		return nil, err
+	}
+
+	kvs := make([]KeyValue, 0, len(flat))
+	for _, k := range sortedKeys(flat) {
+		value, err := JSONValue(flat[k])
+		if err != nil {
+			return nil, err
+		}
+		kvs = append(kvs, KeyValue{Key: k, Value: value})
+	}
+
+	return kvs, nil
+}
+
+// EtcdImport rebuilds a nested map from etcd entries under base, e.g. a prefix range read, reversing
+// EtcdExport.  Entries outside base are ignored, and values must be JSON.
+func EtcdImport(base string, kvs []KeyValue) (map[string]interface{}, error) {
+	base = withSlash(base)
+
+	flat := make(map[string]interface{}, len(kvs))
+	for _, kv := range kvs {
+		if !strings.HasPrefix(kv.Key, base) || len(kv.Key) == len(base) {
+			continue
+		}
+
+		v, err := ParseJSONValue(kv.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", kv.Key, err)
+		}
+		flat[kv.Key[len(base):]] = v
+	}
+
+	return nest(flat, splitSlashKey)
+}
+
+// splitSlashKey takes apart a key joined with etcdStyle.
+func splitSlashKey(key string) ([]string, error) {
+	path := strings.Split(key, etcdStyle.Middle)
+	for i, seg := range path {
+		path[i] = etcdStyle.Escaper.Unescape(seg)
+	}
+	return path, nil
+}
+
+// withSlash ends a non-empty base with a slash.
+func withSlash(base string) string {
+	if base != "" && !strings.HasSuffix(base, "/") {
+		base += "/"
+	}
+	return base
+}
@@ -0,0 +1,58 @@
+package flatten
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEtcdExport(t *testing.T) {
+	nested := map[string]interface{}{
+		"db": map[string]interface{}{
+			"host": "localhost",
+			"port": 5432.0,
+		},
+		"paths": []interface{}{"/a", "/b"},
+		"a/b":   true,
+	}
+
+	got, err := EtcdExport("/config/app", nested)
+	if err != nil {
+		t.Fatalf("failed to export: %v", err)
+	}
+
+	want := []KeyValue{
+		{"/config/app/a%2Fb", []byte("true")},
+		{"/config/app/db/host", []byte(`"localhost"`)},
+		{"/config/app/db/port", []byte("5432")},
+		{"/config/app/paths/0", []byte(`"/a"`)},
+		{"/config/app/paths/1", []byte(`"/b"`)},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %q wanted: %q", got, want)
+	}
+
+	back, err := EtcdImport("/config/app/", append(got, KeyValue{"/other/x", []byte("1")}))
+	if err != nil {
+		t.Fatalf("failed to import: %v", err)
+	}
+	if !reflect.DeepEqual(back, nested) {
+		t.Errorf("round trip mismatch, got: %v wanted: %v", back, nested)
+	}
+}
+
+func TestEtcdImportErrors(t *testing.T) {
+	cases := []struct {
+		kvs []KeyValue
+	}{
+		// 1
+		{[]KeyValue{{"/c/a", []byte("not json")}}},
+		// 2
+		{[]KeyValue{{"/c/a", []byte("1")}, {"/c/a/b", []byte("2")}}},
+	}
+
+	for i, test := range cases {
+		if _, err := EtcdImport("/c", test.kvs); err == nil {
+			t.Errorf("%d: expected an error", i+1)
+		}
+	}
+}
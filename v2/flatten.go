@@ -3,22 +3,46 @@ package flatten
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"regexp"
 	"strconv"
 )
 
 // The style of keys.  If there is an input with two
 // nested keys "f" and "g", with "f" at the root,
-//    { "f": { "g": ... } }
+//
+//	{ "f": { "g": ... } }
+//
 // the output will be the concatenation
-//    f{Middle}{Before}g{After}...
+//
+//	f{Middle}{Before}g{After}...
+//
 // Any struct element may be blank.
 // If you use Middle, you will probably leave Before & After blank, and vice-versa.
 // See examples in flatten_test.go and the "Default styles" here.
+//
+// Slice indexes are joined the same way, unless IndexBefore or IndexAfter is set, in which case
+// they alone surround the index, e.g. "f[1]" rather than "f.1".
+//
+// A Sanitizer, if set, rewrites each map key segment for the target system.  An Escaper, if set, is
+// then applied, to keep separator characters occurring within original keys from making the compound
+// key ambiguous.  A FinalSanitizer, if set, rewrites each complete key, prefix included, e.g. to cap
+// its length.
+//
+// Unflattening splits keys with SplitKey, which parses any style made of these fields, or with a
+// Splitter, for keys it cannot.
 type SeparatorStyle struct {
 	Before string // Prepend to key
 	Middle string // Add between keys
 	After  string // Append to key
+
+	IndexBefore string // Prepend to slice index, in place of Before & Middle (optional)
+	IndexAfter  string // Append to slice index, in place of After (optional)
+
+	Sanitizer      KeySanitizer // Rewrite each key segment (optional)
+	Escaper        Escaper      // Escape each key segment (optional)
+	FinalSanitizer KeySanitizer // Rewrite each complete key (optional)
+	Splitter       KeySplitter  // Split each complete key, when unflattening, in place of SplitKey's parsing (optional)
 }
 
 // Default styles
@@ -29,23 +53,58 @@ var (
 	// Separate with path-like slashes, e.g. a/b/1/c/d
 	PathStyle = SeparatorStyle{Middle: "/"}
 
-	// Separate ala Rails, e.g. "a[b][c][1][d]"
-	RailsStyle = SeparatorStyle{Before: "[", After: "]"}
+	// Separate with slashes, percent-encoding within keys all but RFC 3986 unreserved characters, for
+	// use as URL paths, e.g. "a/b%2Fc/1/d%20e"
+	URIPathStyle = SeparatorStyle{Middle: "/", Escaper: URIEscaper{}}
+
+	// Separate ala Rails, e.g. "a[b][c][1][d]", percent-encoding brackets within keys, e.g. "a[b%5B0%5D]"
+	RailsStyle = SeparatorStyle{Before: "[", After: "]", Escaper: PercentEscaper("[]")}
+
+	// Separate with Windows-like backslashes, for registry-like paths, e.g. `a\b\1\c\d`, percent-encoding
+	// backslashes within keys, e.g. `a\C:%5CTemp`
+	BackslashStyle = SeparatorStyle{Middle: `\`, Escaper: PercentEscaper(`\`)}
 
 	// Separate with underscores, e.g. "a_b_1_c_d"
 	UnderscoreStyle = SeparatorStyle{Middle: "_"}
+
+	// Separate ala Spring Boot properties, with kebab-cased keys, e.g. "my-app.pool-size", "a.list[1].c"
+	SpringStyle = SeparatorStyle{Middle: ".", IndexBefore: "[", IndexAfter: "]", Sanitizer: kebabCase{}}
 )
 
 // Nested input must be a map or slice
 var NotValidInputError = errors.New("Not a valid input: map or slice")
 
 // Flatten generates a flat map from a nested one.  The original may include values of type map, slice and scalar,
-// but not struct.  Keys in the flat map will be a compound of descending map keys and slice iterations.
-// The presentation of keys is set by style.  A prefix is joined to each key.
+// but not struct.  Maps with string keys, slices and arrays of any type, e.g. map[string]string, are flattened
+// alike, as are the map[interface{}]interface{} of YAML decoders, their keys formatted; byte slices and
+// arrays are kept as []byte values.  json.RawMessage values are decoded in place, so keys descend into
+// them.  Values with their own JSON form, e.g. time.Time, appear as in JSON output, and with a text form,
+// e.g. net.IP, as strings.  Keys in the flat map will be a compound of descending map keys and slice
+// iterations.  The presentation of keys is set by style.  A prefix is joined to each key.
 func Flatten(nested map[string]interface{}, prefix string, style SeparatorStyle) (map[string]interface{}, error) {
+	return settings{prefix: prefix, style: style}.flatten("Flatten", nested)
+}
+
+// FlattenInto is Flatten, adding the keys to dst, an existing flat map, e.g. to gather several documents
+// under distinct prefixes.  Keys already in dst are replaced.  Upon an error, dst may hold some of the new
+// keys.
+func FlattenInto(dst, nested map[string]interface{}, prefix string, style SeparatorStyle) error {
+	return settings{prefix: prefix, style: style}.flattenInto("FlattenInto", dst, nested)
+}
+
+// FlattenMap is Flatten for maps of any value type, e.g. map[string]string or map[string][]int, so
+// callers need not convert them to map[string]interface{} first.
+func FlattenMap[V any](nested map[string]V, prefix string, style SeparatorStyle) (map[string]interface{}, error) {
+	done := observe("FlattenMap", len(nested))
 	flatmap := make(map[string]interface{})
 
-	err := flatten(true, flatmap, nested, prefix, style)
+	generic := make(map[string]interface{}, len(nested))
+	for k, v := range nested {
+		generic[k] = v
+	}
+
+	err := flatten(true, flatmap, generic, prefix, style)
+	done(len(flatmap), err)
 	if err != nil {
 		return nil, err
 	}
@@ -53,73 +112,408 @@ func Flatten(nested map[string]interface{}, prefix string, style SeparatorStyle)
 	return flatmap, nil
 }
 
+// FlattenPartial is Flatten, except that upon an error it also returns the keys flattened until then, so
+// work on a huge document is not lost to one bad value.
+func FlattenPartial(nested map[string]interface{}, prefix string, style SeparatorStyle) (map[string]interface{}, error) {
+	return settings{prefix: prefix, style: style}.flattenPartial("FlattenPartial", nested)
+}
+
 // JSON nested input must be a map
 var NotValidJsonInputError = errors.New("Not a valid input, must be a map")
 
 var isJsonMap = regexp.MustCompile(`^\s*\{`)
 
+var isJsonContainer = regexp.MustCompile(`^\s*[\{\[]`)
+
 // FlattenString generates a flat JSON map from a nested one.  Keys in the flat map will be a compound of
 // descending map keys and slice iterations.  The presentation of keys is set by style.  A prefix is joined
-// to each key.
+// to each key.  Keys are written in sorted order, byte-wise, as encoding/json writes maps, so the output
+// for a document is the same run to run, fit for diffs and caching.
 func FlattenString(nestedstr, prefix string, style SeparatorStyle) (string, error) {
-	if !isJsonMap.MatchString(nestedstr) {
-		return "", NotValidJsonInputError
+	flatb, err := Options{}.flattenJSON("FlattenString", []byte(nestedstr), prefix, style, isJsonMap)
+	if err != nil {
+		return "", err
 	}
 
-	var nested map[string]interface{}
-	err := json.Unmarshal([]byte(nestedstr), &nested)
+	return string(flatb), nil
+}
+
+// FlattenAnyString is FlattenString, also accepting a JSON array at the top, as many APIs return; its
+// keys start with the indexes, e.g. "0.a".
+func FlattenAnyString(nestedstr, prefix string, style SeparatorStyle) (string, error) {
+	flatb, err := Options{}.flattenJSON("FlattenAnyString", []byte(nestedstr), prefix, style, isJsonContainer)
 	if err != nil {
 		return "", err
 	}
 
-	flatmap, err := Flatten(nested, prefix, style)
+	return string(flatb), nil
+}
+
+// FlattenBytes is FlattenString for JSON held as bytes, e.g. HTTP bodies, sparing the copies to and from
+// string.
+func FlattenBytes(nested []byte, prefix string, style SeparatorStyle) ([]byte, error) {
+	return Options{}.flattenJSON("FlattenBytes", nested, prefix, style, isJsonMap)
+}
+
+// flattenJSON flattens a JSON document, which must match accept, under the options.
+func (o Options) flattenJSON(op string, nestedb []byte, prefix string, style SeparatorStyle, accept *regexp.Regexp) (flatb []byte, err error) {
+	flatmap := make(map[string]interface{})
+	done := observe(op, len(nestedb))
+	defer func() { done(len(flatmap), err) }()
+
+	if o.JSONC {
+		nestedb = stripJSONC(nestedb)
+	}
+	if !accept.Match(nestedb) {
+		return nil, NotValidJsonInputError
+	}
+
+	nested, err := o.decodeJSON(nestedb)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	flatb, err := json.Marshal(&flatmap)
+	f := flattener{style: o.styled(style), opts: o}
+	err = f.flatten(true, flatmap, nested, prefix, 1)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	return string(flatb), nil
+	return json.Marshal(&flatmap)
 }
 
 func flatten(top bool, flatMap map[string]interface{}, nested interface{}, prefix string, style SeparatorStyle) error {
+	f := flattener{style: style}
+	return f.flatten(top, flatMap, nested, prefix, 1)
+}
+
+// A flattener carries the settings of one flattening, beyond the style.
+type flattener struct {
+	style    SeparatorStyle
+	trace    *Trace    // Where to explain each key (optional)
+	pipeline *Pipeline // Stages to pass each value through (optional)
+	opts     Options
+	order    *[]string // Where to note keys in the order first set, visiting plain maps by sorted key (optional)
+	structs  bool      // Whether to flatten structs by their fields, following pointers
+
+	visiting   map[ref]bool             // The maps, slices and pointers being flattened, from the top down to the current value
+	sanitized  map[string][]interface{} // Under StrictSanitize, the first path by each key it made
+	origins    map[string][]interface{} // Under a CollisionPolicy, the first path by each key it made
+	collisions map[string][]string      // Under a CollisionPolicy, the paths by each key more than one made
+
+	path []interface{} // While tracing or piping, the map keys (strings) and slice indexes (ints) to the current value
+}
+
+// set stores a flattened pair.
+func (f *flattener) set(flatMap map[string]interface{}, key string, v interface{}) {
+	if f.order != nil {
+		if _, ok := flatMap[key]; !ok {
+			*f.order = append(*f.order, key)
+		}
+	}
+	flatMap[key] = v
+}
+
+// sorting reports whether plain maps are visited by sorted key, for an order to the flat keys, or to
+// collisions.
+func (f *flattener) sorting() bool {
+	return f.order != nil || (f.opts.Collisions != OverwriteOnCollision && f.opts.Collisions != ErrorOnCollision)
+}
+
+// tracking reports whether the path to each value is needed.
+func (f *flattener) tracking() bool {
+	return f.trace != nil || f.pipeline != nil || f.opts.StrictSanitize || f.opts.Collisions != OverwriteOnCollision
+}
+
+func (f *flattener) flatten(top bool, flatMap map[string]interface{}, nested interface{}, prefix string, depth int) error {
+	// put stores the value v at the joined key newKey, a leaf or, with Intermediates, a map or slice.
+	put := func(newKey string, v interface{}) error {
+		key, err := f.shorten(f.style.finish(newKey))
+		if err != nil {
+			return err
+		}
+		if f.opts.InternKeys && f.opts.Interner != nil {
+			key = f.opts.Interner.Intern(key)
+		}
+		if f.trace != nil {
+			f.trace.leaf(newKey, key, f.path, f.style)
+		}
+		if f.opts.StrictSanitize {
+			if err := f.checkSanitized(key); err != nil {
+				return err
+			}
+		}
+		if f.opts.Collisions != OverwriteOnCollision {
+			var ok bool
+			if v, ok = f.collide(flatMap, key, v); !ok {
+				return nil
+			}
+		}
+		if max := f.opts.MaxKeys; max > 0 && len(flatMap) >= max {
+			if _, ok := flatMap[key]; !ok {
+				return &KeyLimitError{Key: key, MaxKeys: max}
+			}
+		}
+		f.set(flatMap, key, v)
+		return nil
+	}
+
 	assign := func(newKey string, v interface{}) error {
+		if f.structs {
+			var ok bool
+			if v, ok = f.opts.Nils.nilValue(v); !ok {
+				return nil
+			}
+		}
+		orig := v
+		v, err := f.unwrap(v)
+		if err != nil {
+			return fmt.Errorf("%w: %q", err, newKey)
+		}
+		if f.pipeline != nil {
+			v = f.pipe(v)
+		}
+
 		switch v.(type) {
-		case map[string]interface{}, []interface{}:
-			if err := flatten(false, flatMap, v, newKey, style); err != nil {
-				return err
+		case map[string]interface{}, []interface{}, OrderedMap, Ranger, anyRanger:
+			if max := f.opts.MaxDepth; max != 0 && depth >= max {
+				if f.opts.DepthOverflow == ErrorOnDepth {
+					return &DepthError{Key: f.style.finish(newKey), MaxDepth: max}
+				}
+				if f.trace != nil {
+					f.trace.add(newKey, KeptWhole, f.path, fmt.Sprintf("at the depth limit of %d", max))
+				}
+				if f.opts.DepthOverflow == MarshalOnDepth {
+					plain, err := f.plain(v)
+					if err != nil {
+						return fmt.Errorf("%w: %q", err, newKey)
+					}
+					b, err := json.Marshal(plain)
+					if err != nil {
+						return fmt.Errorf("%w: %q", err, newKey)
+					}
+					v = string(b)
+				}
+				break
+			}
+			if _, ok := v.([]interface{}); ok && f.opts.KeepArrays {
+				break
+			}
+			if f.opts.KeepEmpty {
+				if empty, ok := emptyOf(v); ok {
+					v = empty
+					break
+				}
+			}
+			r, ok := f.enter(orig)
+			if ok {
+				defer f.leave(r)
+				if f.opts.Intermediates != LeavesOnly {
+					if err := put(newKey, f.opts.intermediate(v)); err != nil {
+						return err
+					}
+				}
+				return f.flatten(false, flatMap, v, newKey, depth+1)
+			}
+			if f.opts.Cycles == ErrorOnCycle {
+				return &CycleError{Key: f.style.finish(newKey)}
 			}
-		default:
-			flatMap[newKey] = v
+			if f.trace != nil {
+				f.trace.add(newKey, CycleCut, f.path, "")
+			}
+			v = nil
+		}
+
+		if v == nil && f.opts.OmitNulls {
+			return nil
+		}
+		if v, err = f.opts.value(v); err != nil {
+			return fmt.Errorf("%w: %q", err, newKey)
+		}
+		v, cut := f.truncate(newKey, v)
+		if s, ok := v.(string); ok && f.opts.Interner != nil {
+			v = f.opts.Interner.Intern(s) // as cut, so long values are not kept whole
+		}
+
+		if err := put(newKey, v); err != nil {
+			return err
+		}
+		if cut && f.opts.MarkTruncated {
+			return put(enkey(false, newKey, "__truncated", f.style), true)
+		}
+		return nil
+	}
+
+	if top {
+		if r, ok := f.enter(nested); ok {
+			defer f.leave(r)
 		}
+	}
+	nested, err := f.unwrap(nested)
+	if err != nil {
+		return err
+	}
 
+	member := func(k string, v interface{}) error {
+		if f.tracking() {
+			f.path = append(f.path, k)
+		}
+		if f.keep(v) {
+			k, err := f.opts.text(k)
+			newKey := enkey(top, prefix, f.rename(k), f.style)
+			if err != nil {
+				return fmt.Errorf("%w: %q", err, newKey)
+			}
+			if err := assign(newKey, v); err != nil {
+				return err
+			}
+		}
+		if f.tracking() {
+			f.path = f.path[:len(f.path)-1]
+		}
 		return nil
 	}
 
-	switch nested.(type) {
+	switch nested := nested.(type) {
 	case map[string]interface{}:
-		for k, v := range nested.(map[string]interface{}) {
-			newKey := enkey(top, prefix, k, style)
-			assign(newKey, v)
+		if f.sorting() {
+			for _, k := range sortedKeys(nested) {
+				if err := member(k, nested[k]); err != nil {
+					return err
+				}
+			}
+			break
+		}
+		for k, v := range nested {
+			if err := member(k, v); err != nil {
+				return err
+			}
+		}
+	case OrderedMap:
+		for _, k := range nested.Keys() {
+			v, _ := nested.Get(k)
+			if err := member(k, v); err != nil {
+				return err
+			}
+		}
+	case Ranger, anyRanger:
+		if err := f.rangeMembers(f.rangeOf(nested), member); err != nil {
+			return err
 		}
 	case []interface{}:
-		for i, v := range nested.([]interface{}) {
-			newKey := enkey(top, prefix, strconv.Itoa(i), style)
-			assign(newKey, v)
+		for i, v := range nested {
+			if f.tracking() {
+				f.path = append(f.path, i)
+			}
+			if f.keep(v) {
+				newKey := joinIndex(top, prefix, f.opts.index(i), f.style)
+				if err := assign(newKey, v); err != nil {
+					return err
+				}
+			}
+			if f.tracking() {
+				f.path = f.path[:len(f.path)-1]
+			}
 		}
 	default:
 		return NotValidInputError
 	}
 
+	if top && f.opts.Collisions == ErrorOnCollision && len(f.collisions) > 0 {
+		return f.collisionError()
+	}
 	return nil
 }
 
+// emptyOf returns an empty map or slice for v, a map or slice, if it is empty, or else false.
+func emptyOf(v interface{}) (interface{}, bool) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return map[string]interface{}{}, len(v) == 0
+	case OrderedMap:
+		return map[string]interface{}{}, len(v.Keys()) == 0
+	case []interface{}:
+		return []interface{}{}, len(v) == 0
+	case Ranger:
+		empty := true
+		v.Range(func(string, interface{}) bool { empty = false; return false })
+		return map[string]interface{}{}, empty
+	case anyRanger:
+		empty := true
+		v.Range(func(interface{}, interface{}) bool { empty = false; return false })
+		return map[string]interface{}{}, empty
+	}
+	return nil, false
+}
+
+// plain converts v, and the maps and slices within, to map[string]interface{} and []interface{}, as
+// json.Marshal writes them in full, e.g. a *sync.Map, which marshals as {}, or an OrderedMap.
+func (f *flattener) plain(v interface{}) (interface{}, error) {
+	orig := v
+	v, err := f.unwrap(v)
+	if err != nil {
+		return nil, err
+	}
+
+	switch v.(type) {
+	case map[string]interface{}, []interface{}, OrderedMap, Ranger, anyRanger:
+		r, ok := f.enter(orig)
+		if !ok {
+			if f.opts.Cycles == CutCycles {
+				return nil, nil
+			}
+			return nil, CyclicInputError
+		}
+		defer f.leave(r)
+	}
+
+	switch v := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, child := range v {
+			if m[k], err = f.plain(child); err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, child := range v {
+			if list[i], err = f.plain(child); err != nil {
+				return nil, err
+			}
+		}
+		return list, nil
+	case OrderedMap:
+		m := make(map[string]interface{})
+		for _, k := range v.Keys() {
+			child, _ := v.Get(k)
+			if m[k], err = f.plain(child); err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+	case Ranger, anyRanger:
+		m := make(map[string]interface{})
+		f.rangeOf(v)(func(k string, child interface{}) bool {
+			m[k], err = f.plain(child)
+			return err == nil
+		})
+		return m, err
+	}
+	return v, nil
+}
+
 func enkey(top bool, prefix, subkey string, style SeparatorStyle) string {
 	key := prefix
 
+	if style.Sanitizer != nil {
+		subkey = style.Sanitizer.SanitizeKey(subkey)
+	}
+	if style.Escaper != nil {
+		subkey = style.Escaper.Escape(subkey)
+	}
+
 	if top {
 		key += subkey
 	} else {
@@ -128,3 +522,24 @@ func enkey(top bool, prefix, subkey string, style SeparatorStyle) string {
 
 	return key
 }
+
+// finish applies the style's FinalSanitizer, if any, to a complete key.
+func (style SeparatorStyle) finish(key string) string {
+	if style.FinalSanitizer != nil {
+		return style.FinalSanitizer.SanitizeKey(key)
+	}
+	return key
+}
+
+func enindex(top bool, prefix string, i int, style SeparatorStyle) string {
+	return joinIndex(top, prefix, strconv.Itoa(i), style)
+}
+
+// joinIndex joins a formatted slice index to prefix, as enindex does.
+func joinIndex(top bool, prefix, index string, style SeparatorStyle) string {
+	if style.IndexBefore == "" && style.IndexAfter == "" {
+		return enkey(top, prefix, index, style)
+	}
+
+	return prefix + style.IndexBefore + index + style.IndexAfter
+}
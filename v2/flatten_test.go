@@ -2,7 +2,9 @@ package flatten
 
 import (
 	"encoding/json"
+	"errors"
 	"reflect"
+	"sort"
 	"strings"
 	"testing"
 	"unicode"
@@ -196,6 +198,71 @@ func TestFlatten(t *testing.T) {
 			"flag-",
 			UnderscoreStyle,
 		},
+		// 7 -- brackets within keys
+		{
+			`{
+				"a[0]": {
+					"b]": "c",
+					"50%": "d"
+				}
+			}`,
+			map[string]interface{}{
+				"a%5B0%5D[b%5D]":  "c",
+				"a%5B0%5D[50%25]": "d",
+			},
+			"",
+			RailsStyle,
+		},
+		// 8
+		{
+			`{
+				"myApp": {
+					"poolSize": 10,
+					"hosts": [
+						"a",
+						{ "port_number": 80 }
+					]
+				}
+			}`,
+			map[string]interface{}{
+				"my-app.pool-size":            10.0,
+				"my-app.hosts[0]":             "a",
+				"my-app.hosts[1].port-number": 80.0,
+			},
+			"",
+			SpringStyle,
+		},
+		// 9
+		{
+			`{
+				"docs": {
+					"a/b": ["x", { "c d": "y" }]
+				}
+			}`,
+			map[string]interface{}{
+				"docs/a%2Fb/0":       "x",
+				"docs/a%2Fb/1/c%20d": "y",
+			},
+			"",
+			URIPathStyle,
+		},
+		// 10
+		{
+			`{
+				"HKLM": {
+					"Temp": "C:\\Temp",
+					"C:\\Temp": ["x"],
+					"50%": "y"
+				}
+			}`,
+			map[string]interface{}{
+				`HKLM\Temp`:        `C:\Temp`,
+				`HKLM\C:%5CTemp\0`: "x",
+				`HKLM\50%25`:       "y",
+			},
+			"",
+			BackslashStyle,
+		},
 	}
 
 	for i, test := range cases {
@@ -358,3 +425,137 @@ func TestFlattenString(t *testing.T) {
 		}
 	}
 }
+
+func TestFlattenStringSorted(t *testing.T) {
+	nested := make(map[string]interface{})
+	keys := make([]string, 0, 50)
+	for i := 0; i < 50; i++ {
+		k := string(rune('a'+i%26)) + strings.Repeat("z", i/26)
+		nested[k] = map[string]interface{}{"b": i, "A": i}
+		keys = append(keys, k+".A", k+".b")
+	}
+	nestedb, _ := json.Marshal(nested)
+
+	sort.Strings(keys)
+
+	for n := 0; n < 20; n++ { // against luck in map order
+		got, err := FlattenString(string(nestedb), "", DotStyle)
+		if err != nil {
+			t.Fatalf("failed to flatten: %v", err)
+		}
+		var order []string
+		dec := json.NewDecoder(strings.NewReader(got))
+		dec.Token()
+		for dec.More() {
+			tok, _ := dec.Token()
+			order = append(order, tok.(string))
+			dec.Token()
+		}
+		if !reflect.DeepEqual(order, keys) {
+			t.Fatalf("%d: mismatch, got: %v wanted: %v", n+1, order, keys)
+		}
+	}
+}
+
+func TestFlattenAnyString(t *testing.T) {
+	cases := []struct {
+		test   string
+		want   string
+		prefix string
+		err    error
+	}{
+		// 1
+		{`[ { "a": 1 }, [ "b" ], "c" ]`, `{"0.a":1,"1.0":"b","2":"c"}`, "", nil},
+		// 2
+		{` { "a": [ 1 ] }`, `{"p-a.0":1}`, "p-", nil},
+		// 3
+		{`[]`, `{}`, "", nil},
+		// 4
+		{`"a"`, ``, "", NotValidJsonInputError},
+	}
+
+	for i, test := range cases {
+		got, err := FlattenAnyString(test.test, test.prefix, DotStyle)
+		if err != test.err {
+			t.Errorf("%d: error mismatch, got: [%v], wanted: [%v]", i+1, err, test.err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%d: mismatch, got: %v wanted: %v", i+1, got, test.want)
+		}
+	}
+}
+
+func TestFlattenBytes(t *testing.T) {
+	cases := []struct {
+		test string
+		want string
+		err  bool
+	}{
+		// 1
+		{`{ "a": { "b": [1, "c"] } }`, `{"a.b.0":1,"a.b.1":"c"}`, false},
+		// 2
+		{`[ "a" ]`, ``, true},
+		// 3
+		{`{ "a": `, ``, true},
+	}
+
+	for i, test := range cases {
+		got, err := FlattenBytes([]byte(test.test), "", DotStyle)
+		if (err != nil) != test.err {
+			t.Errorf("%d: error mismatch, got: [%v]", i+1, err)
+			continue
+		}
+		if string(got) != test.want {
+			t.Errorf("%d: mismatch, got: %s wanted: %s", i+1, got, test.want)
+		}
+	}
+}
+
+func TestFlattenMap(t *testing.T) {
+	got, err := FlattenMap(map[string]string{"a": "b", "c": "d"}, "p.", DotStyle)
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+	if want := map[string]interface{}{"p.a": "b", "p.c": "d"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %v wanted: %v", got, want)
+	}
+
+	got, err = FlattenMap(map[string][]int{"a": {1, 2}}, "", PathStyle)
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+	if want := map[string]interface{}{"a/0": 1, "a/1": 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %v wanted: %v", got, want)
+	}
+
+	got, err = FlattenMap(map[string]map[string]float64{"m": {"x": 0.5}}, "", RailsStyle)
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+	if want := map[string]interface{}{"m[x]": 0.5}; !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %v wanted: %v", got, want)
+	}
+}
+
+func TestFlattenPartial(t *testing.T) {
+	nested := map[string]interface{}{
+		"c": []interface{}{"x", broken{}, "y"},
+	}
+
+	got, err := FlattenPartial(nested, "", DotStyle)
+	if !errors.Is(err, brokenError) {
+		t.Errorf("error mismatch, got: [%v], wanted: [%v]", err, brokenError)
+	}
+	if err != nil && !strings.Contains(err.Error(), `"c.1"`) {
+		t.Errorf("error lacks the key: %v", err)
+	}
+	want := map[string]interface{}{"c.0": "x"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %v wanted: %v", got, want)
+	}
+
+	if got, err := Flatten(nested, "", DotStyle); got != nil || err == nil {
+		t.Errorf("Flatten mismatch, got: %v, %v wanted: nil and an error", got, err)
+	}
+}
@@ -0,0 +1,121 @@
+// Package flattenavro flattens Avro generic records with the styles of package flatten, guided by their
+// schema.
+//
+// It is a module of its own, so that package flatten stays free of the Avro dependency.
+package flattenavro
+
+import (
+	"github.com/hamba/avro/v2"
+	"github.com/jeremywohl/flatten/v2"
+)
+
+// FlattenAvro decodes data, a record in Avro binary under schema, and flattens it as Flatten does.
+func FlattenAvro(schema avro.Schema, data []byte, prefix string, style flatten.SeparatorStyle) (map[string]interface{}, error) {
+	var datum interface{}
+	if err := avro.Unmarshal(schema, data, &datum); err != nil {
+		return nil, err
+	}
+
+	return Flatten(schema, datum, prefix, style)
+}
+
+// Flatten generates a flat map from a generic record of schema, as decoded into an interface{}: records
+// and maps are maps, arrays slices, and fixed values byte arrays.
+//
+// Union values are resolved to the branch they hold, so keys do not name it: a value wrapped in a map
+// keyed by its branch, as {"string": "x"} or {"com.example.Address": {...}}, as decoders give for some
+// or all unions, is unwrapped.  Hence a map value of a union having a map branch, keyed only by "map",
+// is taken for a wrapper.  Nulls are nil.
+func Flatten(schema avro.Schema, datum interface{}, prefix string, style flatten.SeparatorStyle) (map[string]interface{}, error) {
+	m, ok := resolve(schema, datum).(map[string]interface{})
+	if !ok {
+		return nil, flatten.NotValidInputError
+	}
+
+	return flatten.Flatten(m, prefix, style)
+}
+
+// resolve copies v, a value of schema, with union values unwrapped.
+func resolve(schema avro.Schema, v interface{}) interface{} {
+	switch s := schema.(type) {
+	case *avro.RefSchema:
+		return resolve(s.Schema(), v)
+	case *avro.RecordSchema:
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return v
+		}
+		fields := make(map[string]interface{}, len(m))
+		for k, child := range m {
+			fields[k] = child
+		}
+		for _, f := range s.Fields() {
+			if child, ok := m[f.Name()]; ok {
+				fields[f.Name()] = resolve(f.Type(), child)
+			}
+		}
+		return fields
+	case *avro.MapSchema:
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return v
+		}
+		values := make(map[string]interface{}, len(m))
+		for k, child := range m {
+			values[k] = resolve(s.Values(), child)
+		}
+		return values
+	case *avro.ArraySchema:
+		list, ok := v.([]interface{})
+		if !ok {
+			return v
+		}
+		items := make([]interface{}, len(list))
+		for i, child := range list {
+			items[i] = resolve(s.Items(), child)
+		}
+		return items
+	case *avro.UnionSchema:
+		return resolveUnion(s, v)
+	}
+
+	return v
+}
+
+// resolveUnion unwraps a union value keyed by its branch, or else resolves it by the first branch of
+// its shape.
+func resolveUnion(s *avro.UnionSchema, v interface{}) interface{} {
+	if m, ok := v.(map[string]interface{}); ok && len(m) == 1 {
+		for _, t := range s.Types() {
+			if inner, ok := m[branchName(t)]; ok {
+				return resolve(t, inner)
+			}
+		}
+	}
+
+	for _, t := range s.Types() {
+		if ref, ok := t.(*avro.RefSchema); ok {
+			t = ref.Schema()
+		}
+		switch t.(type) {
+		case *avro.RecordSchema, *avro.MapSchema:
+			if _, ok := v.(map[string]interface{}); ok {
+				return resolve(t, v)
+			}
+		case *avro.ArraySchema:
+			if _, ok := v.([]interface{}); ok {
+				return resolve(t, v)
+			}
+		}
+	}
+
+	return v
+}
+
+// branchName is the name a union branch is keyed by: the full name of a named type, or else the type.
+func branchName(t avro.Schema) string {
+	if named, ok := t.(avro.NamedSchema); ok {
+		return named.FullName()
+	}
+	return string(t.Type())
+}
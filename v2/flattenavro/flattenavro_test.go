@@ -0,0 +1,108 @@
+package flattenavro
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hamba/avro/v2"
+	"github.com/jeremywohl/flatten/v2"
+)
+
+var schema = avro.MustParse(`{
+	"type": "record", "name": "User", "namespace": "com.example",
+	"fields": [
+		{"name": "name", "type": "string"},
+		{"name": "email", "type": ["null", "string"]},
+		{"name": "id", "type": ["long", "string"]},
+		{"name": "address", "type": ["null", {
+			"type": "record", "name": "Address",
+			"fields": [{"name": "city", "type": "string"}, {"name": "zip", "type": ["null", "int"]}]
+		}]},
+		{"name": "others", "type": {"type": "array", "items": "Address"}},
+		{"name": "scores", "type": {"type": "map", "values": ["null", "double"]}},
+		{"name": "role", "type": {"type": "enum", "name": "Role", "symbols": ["ADMIN", "USER"]}},
+		{"name": "tag", "type": {"type": "fixed", "name": "Tag", "size": 2}}
+	]
+}`)
+
+func TestFlattenAvro(t *testing.T) {
+	record := map[string]interface{}{
+		"name":    "ann",
+		"email":   "ann@example.com",
+		"id":      map[string]interface{}{"long": int64(7)},
+		"address": map[string]interface{}{"com.example.Address": map[string]interface{}{"city": "Oslo", "zip": nil}},
+		"others":  []interface{}{map[string]interface{}{"city": "Rome", "zip": 100}},
+		"scores":  map[string]interface{}{"math": 1.5, "art": nil},
+		"role":    "ADMIN",
+		"tag":     [2]byte{1, 2},
+	}
+
+	data, err := avro.Marshal(schema, record)
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+
+	got, err := FlattenAvro(schema, data, "", flatten.DotStyle)
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+
+	want := map[string]interface{}{
+		"name":          "ann",
+		"email":         "ann@example.com",
+		"id":            int64(7),
+		"address.city":  "Oslo",
+		"address.zip":   nil,
+		"others.0.city": "Rome",
+		"others.0.zip":  100,
+		"scores.math":   1.5,
+		"scores.art":    nil,
+		"role":          "ADMIN",
+		"tag":           []byte{1, 2},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %#v wanted: %#v", got, want)
+	}
+}
+
+func TestFlatten(t *testing.T) {
+	s := avro.MustParse(`{"type": "record", "name": "E", "fields": [
+		{"name": "v", "type": ["null", "string", {"type": "map", "values": "int"}]},
+		{"name": "w", "type": ["null", {"type": "array", "items": ["int", "string"]}]}
+	]}`)
+
+	cases := []struct {
+		datum interface{}
+		want  map[string]interface{}
+		err   error
+	}{
+		// 1 -- wrapped in every union, as goavro gives them
+		{
+			map[string]interface{}{
+				"v": map[string]interface{}{"string": "x"},
+				"w": map[string]interface{}{"array": []interface{}{map[string]interface{}{"int": 1}, map[string]interface{}{"string": "y"}}},
+			},
+			map[string]interface{}{"v": "x", "w.0": 1, "w.1": "y"},
+			nil,
+		},
+		// 2 -- unwrapped
+		{
+			map[string]interface{}{"v": map[string]interface{}{"a": 1, "b": 2}, "w": nil},
+			map[string]interface{}{"v.a": 1, "v.b": 2, "w": nil},
+			nil,
+		},
+		// 3
+		{"x", nil, flatten.NotValidInputError},
+	}
+
+	for i, test := range cases {
+		got, err := Flatten(s, test.datum, "", flatten.DotStyle)
+		if err != test.err {
+			t.Errorf("%d: error mismatch, got: [%v], wanted: [%v]", i+1, err, test.err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%d: mismatch, got: %v wanted: %v", i+1, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+// Package flattenbson flattens MongoDB documents into the dot-notation paths of update operators, e.g.
+// for $set.
+//
+// It is a module of its own, so that package flatten stays free of the BSON dependency.
+package flattenbson
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jeremywohl/flatten/v2"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// A field name cannot stand in a dot-notation path
+var NotValidFieldError = errors.New("Not a valid field name: empty, or holding a dot or leading $")
+
+// Flatten generates the dot-notation paths of a document, bson.D, bson.M or map[string]interface{},
+// as an ordered bson.D: bson.D in its order, arrays by index, and maps by sorted key.  A prefix is joined
+// to each path, as with package flatten, e.g. "profile." for "profile.name".
+//
+// Leaves are kept as they are, so ObjectIDs, dates and decimals stay BSON types.  Empty documents and
+// arrays add no paths.  Field names which are empty, hold a dot or start with $ would make paths which
+// mean something else, and fail with NotValidFieldError.
+func Flatten(doc interface{}, prefix string) (bson.D, error) {
+	switch doc.(type) {
+	case bson.D, bson.M, map[string]interface{}:
+	default:
+		return nil, flatten.NotValidInputError
+	}
+
+	var paths bson.D
+	if err := walk(&paths, doc, prefix, true); err != nil {
+		return nil, err
+	}
+
+	return paths, nil
+}
+
+// SetUpdate makes a $set update of the paths of doc, for UpdateOne and the like, e.g.
+//
+//	{"$set": {"profile.name": "Ann", "profile.tags.0": "a"}}
+//
+// setting the fields given and leaving the others of each subdocument.
+func SetUpdate(doc interface{}, prefix string) (bson.D, error) {
+	paths, err := Flatten(doc, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	return bson.D{{Key: "$set", Value: paths}}, nil
+}
+
+func walk(paths *bson.D, node interface{}, path string, top bool) error {
+	field := func(k string, v interface{}) error {
+		if k == "" || strings.Contains(k, ".") || strings.HasPrefix(k, "$") {
+			return fmt.Errorf("%w: %q", NotValidFieldError, k)
+		}
+		return walk(paths, v, join(path, k, top), false)
+	}
+
+	switch node := node.(type) {
+	case bson.D:
+		for _, e := range node {
+			if err := field(e.Key, e.Value); err != nil {
+				return err
+			}
+		}
+	case bson.M:
+		return walk(paths, map[string]interface{}(node), path, top)
+	case map[string]interface{}:
+		keys := make([]string, 0, len(node))
+		for k := range node {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if err := field(k, node[k]); err != nil {
+				return err
+			}
+		}
+	case bson.A:
+		return walk(paths, []interface{}(node), path, top)
+	case []interface{}:
+		for i, v := range node {
+			if err := walk(paths, v, join(path, strconv.Itoa(i), top), false); err != nil {
+				return err
+			}
+		}
+	default:
+		*paths = append(*paths, bson.E{Key: path, Value: node})
+	}
+
+	return nil
+}
+
+func join(path, seg string, top bool) string {
+	if top {
+		return path + seg
+	}
+	return path + "." + seg
+}
@@ -0,0 +1,81 @@
+package flattenbson
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jeremywohl/flatten/v2"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestFlatten(t *testing.T) {
+	id := bson.NewObjectID()
+	at := bson.NewDateTimeFromTime(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC))
+
+	cases := []struct {
+		doc    interface{}
+		prefix string
+		want   bson.D
+		err    error
+	}{
+		// 1 -- order kept, BSON types kept
+		{
+			bson.D{
+				{Key: "z", Value: id},
+				{Key: "a", Value: bson.D{{Key: "y", Value: at}, {Key: "b", Value: bson.A{"p", bson.M{"q": 1, "c": true}}}}},
+				{Key: "empty", Value: bson.D{}},
+			},
+			"",
+			bson.D{
+				{Key: "z", Value: id},
+				{Key: "a.y", Value: at},
+				{Key: "a.b.0", Value: "p"},
+				{Key: "a.b.1.c", Value: true},
+				{Key: "a.b.1.q", Value: 1},
+			},
+			nil,
+		},
+		// 2
+		{
+			map[string]interface{}{"name": "Ann", "tags": []interface{}{"a"}},
+			"profile.",
+			bson.D{{Key: "profile.name", Value: "Ann"}, {Key: "profile.tags.0", Value: "a"}},
+			nil,
+		},
+		// 3
+		{bson.M{"a.b": 1}, "", nil, NotValidFieldError},
+		// 4
+		{bson.M{"a": bson.M{"$inc": 1}}, "", nil, NotValidFieldError},
+		// 5
+		{bson.A{1}, "", nil, flatten.NotValidInputError},
+	}
+
+	for i, test := range cases {
+		got, err := Flatten(test.doc, test.prefix)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%d: error mismatch, got: [%v], wanted: [%v]", i+1, err, test.err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%d: mismatch, got: %v wanted: %v", i+1, got, test.want)
+		}
+	}
+}
+
+func TestSetUpdate(t *testing.T) {
+	got, err := SetUpdate(bson.M{"profile": bson.M{"name": "Ann"}}, "")
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+
+	want := bson.D{{Key: "$set", Value: bson.D{{Key: "profile.name", Value: "Ann"}}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %v wanted: %v", got, want)
+	}
+
+	if _, err := bson.Marshal(got); err != nil {
+		t.Errorf("failed to marshal: %v", err)
+	}
+}
@@ -0,0 +1,10 @@
+module github.com/jeremywohl/flatten/v2/flattenbson
+
+go 1.25.0
+
+require (
+	github.com/jeremywohl/flatten/v2 v2.1.0
+	go.mongodb.org/mongo-driver/v2 v2.9.1
+)
+
+replace github.com/jeremywohl/flatten/v2 => ../
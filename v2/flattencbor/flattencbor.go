@@ -0,0 +1,62 @@
+// Package flattencbor flattens CBOR documents with the styles of package flatten.
+//
+// It is a module of its own, so that package flatten stays free of the CBOR dependency.
+package flattencbor
+
+import (
+	"fmt"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/jeremywohl/flatten/v2"
+)
+
+var decMode, _ = cbor.DecOptions{
+	IntDec:               cbor.IntDecConvertSignedOrBigInt,
+	UnrecognizedTagToAny: cbor.UnrecognizedTagContentToAny,
+}.DecMode()
+
+// FlattenCBOR generates a flat map from a CBOR map.  Integers are int64, save big.Int for those beyond
+// it, bignums included; byte strings are []byte.  Map keys of other types than text, e.g. integers as
+// are common in IoT payloads, are flattened as their text, 1 as "1".  Date-time tags are strings in RFC
+// 3339, as Flatten renders time.Time, and other tags are dropped for their content.
+func FlattenCBOR(data []byte, prefix string, style flatten.SeparatorStyle) (map[string]interface{}, error) {
+	var nested interface{}
+	if err := decMode.Unmarshal(data, &nested); err != nil {
+		return nil, err
+	}
+
+	m, ok := normalize(nested).(map[string]interface{})
+	if !ok {
+		return nil, flatten.NotValidInputError
+	}
+
+	return flatten.Flatten(m, prefix, style)
+}
+
+// FlattenCBORBytes is FlattenCBOR, encoding the flat map as CBOR.
+func FlattenCBORBytes(data []byte, prefix string, style flatten.SeparatorStyle) ([]byte, error) {
+	flat, err := FlattenCBOR(data, prefix, style)
+	if err != nil {
+		return nil, err
+	}
+
+	return cbor.Marshal(flat)
+}
+
+// normalize converts, depth first, maps keyed by interface{} into maps keyed by string.
+func normalize(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, child := range v {
+			m[fmt.Sprint(k)] = normalize(child)
+		}
+		return m
+	case []interface{}:
+		for i, child := range v {
+			v[i] = normalize(child)
+		}
+	}
+
+	return v
+}
@@ -0,0 +1,96 @@
+package flattencbor
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/jeremywohl/flatten/v2"
+)
+
+func mustMarshal(t *testing.T, v interface{}) []byte {
+	b, err := cbor.Marshal(v)
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+	return b
+}
+
+func TestFlattenCBOR(t *testing.T) {
+	huge, _ := new(big.Int).SetString("18446744073709551616", 10)
+
+	cases := []struct {
+		doc  interface{}
+		want map[string]interface{}
+		err  bool
+	}{
+		// 1
+		{
+			map[string]interface{}{
+				"sensor": map[string]interface{}{"id": 7, "temp": -1.5, "raw": []byte{1}, "on": true},
+				"seen":   []interface{}{"a", nil},
+			},
+			map[string]interface{}{
+				"sensor.id":   int64(7),
+				"sensor.temp": -1.5,
+				"sensor.raw":  []byte{1},
+				"sensor.on":   true,
+				"seen.0":      "a",
+				"seen.1":      nil,
+			},
+			false,
+		},
+		// 2 -- integer keys
+		{
+			map[int]interface{}{1: "temp", 2: map[int]int{-1: 3}},
+			map[string]interface{}{"1": "temp", "2.-1": int64(3)},
+			false,
+		},
+		// 3 -- tags
+		{
+			map[string]interface{}{
+				"at":     cbor.Tag{Number: 1, Content: 1714557600},
+				"uri":    cbor.Tag{Number: 32, Content: "http://x"},
+				"big":    huge,
+				"uint64": uint64(1 << 63),
+			},
+			map[string]interface{}{
+				"at":     time.Unix(1714557600, 0).Format(time.RFC3339),
+				"uri":    "http://x",
+				"big":    *huge,
+				"uint64": *new(big.Int).SetUint64(1 << 63),
+			},
+			false,
+		},
+		// 4
+		{[]interface{}{1}, nil, true},
+	}
+
+	for i, test := range cases {
+		got, err := FlattenCBOR(mustMarshal(t, test.doc), "", flatten.DotStyle)
+		if (err != nil) != test.err {
+			t.Errorf("%d: error mismatch, got: [%v]", i+1, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%d: mismatch, got: %#v wanted: %#v", i+1, got, test.want)
+		}
+	}
+}
+
+func TestFlattenCBORBytes(t *testing.T) {
+	flat, err := FlattenCBORBytes(mustMarshal(t, map[string]interface{}{"a": map[int]string{1: "b"}}), "", flatten.PathStyle)
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+
+	var got map[string]interface{}
+	if err := cbor.Unmarshal(flat, &got); err != nil {
+		t.Fatalf("failed to decode: %v", err)
+	}
+	if want := map[string]interface{}{"a/1": "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %v wanted: %v", got, want)
+	}
+}
@@ -0,0 +1,12 @@
+module github.com/jeremywohl/flatten/v2/flattencbor
+
+go 1.21
+
+require (
+	github.com/fxamacker/cbor/v2 v2.9.4
+	github.com/jeremywohl/flatten/v2 v2.1.0
+)
+
+require github.com/x448/float16 v0.8.4 // indirect
+
+replace github.com/jeremywohl/flatten/v2 => ../
@@ -0,0 +1,163 @@
+// Package flattencue flattens CUE values, via their concrete data, with the styles of package flatten.
+//
+// It is a module of its own, so that package flatten stays free of the CUE dependency.
+package flattencue
+
+import (
+	"encoding/json"
+	"math/big"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/jeremywohl/flatten/v2"
+)
+
+// A NonConcreteError lists the paths of a CUE value which have no concrete value, e.g. a field
+// declared as `port: int`, as CUE itself writes them.
+type NonConcreteError struct {
+	Paths []string
+}
+
+func (e *NonConcreteError) Error() string {
+	return "Not a concrete value: " + strings.Join(e.Paths, ", ")
+}
+
+// Flatten generates a flat map from a CUE value, which must be a struct.  Defaults apply, and
+// definitions, hidden and optional fields are left out, as when exporting.  If some values are not
+// concrete, Flatten returns a *NonConcreteError listing them all.
+func Flatten(v cue.Value, prefix string, style flatten.SeparatorStyle) (map[string]interface{}, error) {
+	nested, err := toData(v)
+	if err != nil {
+		return nil, err
+	}
+
+	m, ok := nested.(map[string]interface{})
+	if !ok {
+		return nil, flatten.NotValidInputError
+	}
+
+	flatmap, err := flatten.Flatten(m, prefix, style)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range flatmap {
+		if b, ok := v.(bigInt); ok {
+			flatmap[k] = b.i
+		}
+	}
+
+	return flatmap, nil
+}
+
+// A bigInt carries a *big.Int through flattening, which would otherwise give it, as a json.Marshaler,
+// as its decoded JSON, cutting it to a float64.
+type bigInt struct{ i *big.Int }
+
+// FlattenString compiles CUE source and generates a flat JSON map from the resulting value.
+func FlattenString(src, prefix string, style flatten.SeparatorStyle) (string, error) {
+	v := cuecontext.New().CompileString(src)
+	if err := v.Err(); err != nil {
+		return "", err
+	}
+
+	flatmap, err := Flatten(v, prefix, style)
+	if err != nil {
+		return "", err
+	}
+
+	flatb, err := json.Marshal(&flatmap)
+	if err != nil {
+		return "", err
+	}
+
+	return string(flatb), nil
+}
+
+// toData converts v to maps, slices and scalars, as encoding/json produces them, save that integers
+// are int64, or *big.Int beyond its range.
+func toData(v cue.Value) (interface{}, error) {
+	var incomplete []string
+
+	var convert func(v cue.Value) (interface{}, error)
+	convert = func(v cue.Value) (interface{}, error) {
+		v, _ = v.Default()
+
+		if err := v.Err(); err != nil && v.IsConcrete() {
+			return nil, err
+		}
+
+		switch v.Kind() {
+		case cue.StructKind:
+			iter, err := v.Fields()
+			if err != nil {
+				return nil, err
+			}
+			m := make(map[string]interface{})
+			for iter.Next() {
+				child, err := convert(iter.Value())
+				if err != nil {
+					return nil, err
+				}
+				m[label(iter.Selector())] = child
+			}
+			return m, nil
+
+		case cue.ListKind:
+			iter, err := v.List()
+			if err != nil {
+				return nil, err
+			}
+			list := []interface{}{}
+			for iter.Next() {
+				child, err := convert(iter.Value())
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, child)
+			}
+			return list, nil
+
+		case cue.NullKind:
+			return nil, nil
+		case cue.BoolKind:
+			return v.Bool()
+		case cue.StringKind:
+			return v.String()
+		case cue.BytesKind:
+			return v.Bytes()
+		case cue.FloatKind:
+			return v.Float64()
+		case cue.IntKind:
+			if i, err := v.Int64(); err == nil {
+				return i, nil
+			}
+			i, err := v.Int(nil)
+			if err != nil {
+				return nil, err
+			}
+			return bigInt{i}, nil
+		}
+
+		incomplete = append(incomplete, v.Path().String())
+		return nil, nil
+	}
+
+	data, err := convert(v)
+	if err != nil {
+		return nil, err
+	}
+	if len(incomplete) > 0 {
+		return nil, &NonConcreteError{Paths: incomplete}
+	}
+
+	return data, nil
+}
+
+// label is the plain name of a field, without the quotes CUE needs for some.
+func label(sel cue.Selector) string {
+	if sel.IsString() {
+		return sel.Unquoted()
+	}
+	return sel.String()
+}
@@ -0,0 +1,91 @@
+package flattencue
+
+import (
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/jeremywohl/flatten/v2"
+)
+
+func TestFlatten(t *testing.T) {
+	src := `
+		#Port: int & >0
+		app: {
+			name:    "api"
+			port:    #Port | *8080
+			debug:   false
+			ratio:   1.5
+			hosts: ["a", "b"]
+			"k.8s": null
+			_hidden: "secret"
+			opt?:    "maybe"
+		}
+		big: 123456789012345678901234567890
+	`
+
+	got, err := Flatten(cuecontext.New().CompileString(src), "", flatten.PathStyle)
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+
+	bigint, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	want := map[string]interface{}{
+		"app/name":    "api",
+		"app/port":    int64(8080),
+		"app/debug":   false,
+		"app/ratio":   1.5,
+		"app/hosts/0": "a",
+		"app/hosts/1": "b",
+		"app/k.8s":    nil,
+		"big":         bigint,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %v wanted: %v", got, want)
+	}
+}
+
+func TestFlattenNonConcrete(t *testing.T) {
+	src := `
+		a: { b: int, c: "ok" }
+		d: [string]
+	`
+
+	_, err := Flatten(cuecontext.New().CompileString(src), "", flatten.DotStyle)
+
+	var nc *NonConcreteError
+	if !errors.As(err, &nc) {
+		t.Fatalf("error mismatch, got: [%v], wanted: a NonConcreteError", err)
+	}
+	if want := []string{"a.b", "d[0]"}; !reflect.DeepEqual(nc.Paths, want) {
+		t.Errorf("paths mismatch, got: %v wanted: %v", nc.Paths, want)
+	}
+}
+
+func TestFlattenString(t *testing.T) {
+	cases := []struct {
+		src  string
+		want string
+		err  bool
+	}{
+		// 1
+		{`a: b: c: "d"`, `{"a.b.c":"d"}`, false},
+		// 2
+		{`a: 1 & 2`, ``, true},
+		// 3
+		{`[1, 2]`, ``, true},
+	}
+
+	for i, test := range cases {
+		got, err := FlattenString(test.src, "", flatten.DotStyle)
+		if (err != nil) != test.err {
+			t.Errorf("%d: error mismatch, got: [%v]", i+1, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%d: mismatch, got: %v wanted: %v", i+1, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+// Package flattenhcl flattens HCL configurations, as of Terraform and Nomad, with the styles of package
+// flatten.
+//
+// It is a module of its own, so that package flatten stays free of the HCL dependency.
+package flattenhcl
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/jeremywohl/flatten/v2"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// FlattenHCL generates a flat map from an HCL configuration in native syntax, named filename for its
+// diagnostics.  A block is keyed by its type followed by its labels, so the ami attribute of
+//
+//	resource "aws_instance" "web" { ami = "ami-1" }
+//
+// is "resource.aws_instance.web.ami" with DotStyle.  Blocks repeated with the same type and labels,
+// e.g. several ingress blocks, become a slice.  Attributes are literal values, numbers float64 as in
+// JSON; those referring to variables or functions, which have no value without a context, are kept as
+// their source text, e.g. "var.ami".
+func FlattenHCL(src []byte, filename, prefix string, style flatten.SeparatorStyle) (map[string]interface{}, error) {
+	file, diags := hclsyntax.ParseConfig(src, filename, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	nested, err := nestBody(file.Body.(*hclsyntax.Body), src)
+	if err != nil {
+		return nil, err
+	}
+
+	return flatten.Flatten(nested, prefix, style)
+}
+
+// nestBody builds the nested map of a body, its attributes and blocks.
+func nestBody(body *hclsyntax.Body, src []byte) (map[string]interface{}, error) {
+	node := make(map[string]interface{})
+
+	for name, attr := range body.Attributes {
+		v, diags := attr.Expr.Value(nil)
+		if diags.HasErrors() {
+			node[name] = string(attr.Expr.Range().SliceBytes(src))
+			continue
+		}
+		node[name] = fromCty(v)
+	}
+
+	// Group blocks by type and labels, in order, so repeats make slices.
+	var paths [][]string
+	groups := make(map[string][]interface{})
+	for _, block := range body.Blocks {
+		child, err := nestBody(block.Body, src)
+		if err != nil {
+			return nil, err
+		}
+
+		path := append([]string{block.Type}, block.Labels...)
+		id := fmt.Sprintf("%q", path)
+		if _, ok := groups[id]; !ok {
+			paths = append(paths, path)
+		}
+		groups[id] = append(groups[id], child)
+	}
+
+	for _, path := range paths {
+		group := groups[fmt.Sprintf("%q", path)]
+		var v interface{} = group[0]
+		if len(group) > 1 {
+			v = group
+		}
+		if err := place(node, path, v); err != nil {
+			return nil, err
+		}
+	}
+
+	return node, nil
+}
+
+// place sets v at path under node, adding maps for block labels as needed.
+func place(node map[string]interface{}, path []string, v interface{}) error {
+	for i, seg := range path {
+		existing, ok := node[seg]
+		if i == len(path)-1 {
+			if ok {
+				return fmt.Errorf("%w: %q", flatten.LeafParentConflictError, path)
+			}
+			node[seg] = v
+			break
+		}
+
+		if !ok {
+			m := make(map[string]interface{})
+			node[seg] = m
+			node = m
+			continue
+		}
+		m, isMap := existing.(map[string]interface{})
+		if !isMap {
+			return fmt.Errorf("%w: %q", flatten.LeafParentConflictError, path[:i+1])
+		}
+		node = m
+	}
+
+	return nil
+}
+
+// fromCty converts a known cty value to the types of decoded JSON.
+func fromCty(v cty.Value) interface{} {
+	if v.IsNull() {
+		return nil
+	}
+
+	t := v.Type()
+	switch {
+	case t == cty.String:
+		return v.AsString()
+	case t == cty.Number:
+		f, _ := v.AsBigFloat().Float64()
+		return f
+	case t == cty.Bool:
+		return v.True()
+	case t.IsListType() || t.IsTupleType() || t.IsSetType():
+		list := make([]interface{}, 0, v.LengthInt())
+		for it := v.ElementIterator(); it.Next(); {
+			_, elem := it.Element()
+			list = append(list, fromCty(elem))
+		}
+		return list
+	case t.IsMapType() || t.IsObjectType():
+		m := make(map[string]interface{}, v.LengthInt())
+		for it := v.ElementIterator(); it.Next(); {
+			k, elem := it.Element()
+			m[k.AsString()] = fromCty(elem)
+		}
+		return m
+	}
+
+	return v.GoString()
+}
@@ -0,0 +1,80 @@
+package flattenhcl
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/jeremywohl/flatten/v2"
+)
+
+func TestFlattenHCL(t *testing.T) {
+	cases := []struct {
+		src  string
+		want map[string]interface{}
+		err  error
+	}{
+		// 1
+		{
+			`
+region = "us-east-1"
+
+resource "aws_instance" "web" {
+  ami   = var.ami
+  count = 2
+  tags  = { Name = "web", tier = "front" }
+
+  ingress {
+    port = 80
+  }
+  ingress {
+    port = 443
+  }
+}
+
+resource "aws_instance" "db" {
+  ami  = "ami-2"
+  zones = ["a", "b"]
+  ebs_optimized = true
+  note = null
+}
+`,
+			map[string]interface{}{
+				"region":                                   "us-east-1",
+				"resource.aws_instance.web.ami":            "var.ami",
+				"resource.aws_instance.web.count":          2.0,
+				"resource.aws_instance.web.tags.Name":      "web",
+				"resource.aws_instance.web.tags.tier":      "front",
+				"resource.aws_instance.web.ingress.0.port": 80.0,
+				"resource.aws_instance.web.ingress.1.port": 443.0,
+				"resource.aws_instance.db.ami":             "ami-2",
+				"resource.aws_instance.db.zones.0":         "a",
+				"resource.aws_instance.db.zones.1":         "b",
+				"resource.aws_instance.db.ebs_optimized":   true,
+				"resource.aws_instance.db.note":            nil,
+			},
+			nil,
+		},
+		// 2
+		{
+			"job = 1\njob \"api\" {}\n",
+			nil,
+			flatten.LeafParentConflictError,
+		},
+	}
+
+	for i, test := range cases {
+		got, err := FlattenHCL([]byte(test.src), "test.hcl", "", flatten.DotStyle)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%d: error mismatch, got: [%v], wanted: [%v]", i+1, err, test.err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%d: mismatch, got: %v wanted: %v", i+1, got, test.want)
+		}
+	}
+
+	if _, err := FlattenHCL([]byte(`a = {`), "bad.hcl", "", flatten.DotStyle); err == nil {
+		t.Errorf("syntax error: expected an error")
+	}
+}
@@ -0,0 +1,113 @@
+// Package flattenmsgpack flattens MessagePack documents with the styles of package flatten, and
+// unflattens them back, without passing through JSON.
+//
+// It is a module of its own, so that package flatten stays free of the MessagePack dependency.
+package flattenmsgpack
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+
+	"github.com/jeremywohl/flatten/v2"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// FlattenMsgpack generates a flat map from a MessagePack map.  Integers are int64, save uint64 for those
+// beyond it, and floats float64; binary values are []byte, and timestamps strings in RFC 3339, as Flatten
+// renders time.Time.  Map keys of other types than string, e.g. integers, are flattened as their text, 1
+// as "1".
+func FlattenMsgpack(data []byte, prefix string, style flatten.SeparatorStyle) (map[string]interface{}, error) {
+	nested, err := decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	m, ok := nested.(map[string]interface{})
+	if !ok {
+		return nil, flatten.NotValidInputError
+	}
+
+	return flatten.Flatten(m, prefix, style)
+}
+
+// FlattenMsgpackBytes is FlattenMsgpack, encoding the flat map as MessagePack.
+func FlattenMsgpackBytes(data []byte, prefix string, style flatten.SeparatorStyle) ([]byte, error) {
+	flat, err := FlattenMsgpack(data, prefix, style)
+	if err != nil {
+		return nil, err
+	}
+
+	return msgpack.Marshal(flat)
+}
+
+// UnflattenMsgpack generates a nested MessagePack map from a flat one, as Unflatten does.
+func UnflattenMsgpack(data []byte, style flatten.SeparatorStyle) ([]byte, error) {
+	flat, err := decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	m, ok := flat.(map[string]interface{})
+	if !ok {
+		return nil, flatten.NotValidInputError
+	}
+
+	nested, err := flatten.Unflatten(m, style)
+	if err != nil {
+		return nil, err
+	}
+
+	return msgpack.Marshal(nested)
+}
+
+func decode(data []byte) (interface{}, error) {
+	dec := msgpack.NewDecoder(bytes.NewReader(data))
+	dec.SetMapDecoder(func(d *msgpack.Decoder) (interface{}, error) {
+		return d.DecodeUntypedMap()
+	})
+
+	v, err := dec.DecodeInterface()
+	if err != nil {
+		return nil, err
+	}
+
+	return normalize(v), nil
+}
+
+// normalize converts, depth first, maps keyed by interface{} into maps keyed by string, and widens
+// numbers, which decode into the smallest type holding them.
+func normalize(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, child := range v {
+			m[fmt.Sprint(k)] = normalize(child)
+		}
+		return m
+	case []interface{}:
+		for i, child := range v {
+			v[i] = normalize(child)
+		}
+	case int8:
+		return int64(v)
+	case int16:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case uint8:
+		return int64(v)
+	case uint16:
+		return int64(v)
+	case uint32:
+		return int64(v)
+	case uint64:
+		if v <= math.MaxInt64 {
+			return int64(v)
+		}
+	case float32:
+		return float64(v)
+	}
+
+	return v
+}
@@ -0,0 +1,97 @@
+package flattenmsgpack
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jeremywohl/flatten/v2"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func mustMarshal(t *testing.T, v interface{}) []byte {
+	b, err := msgpack.Marshal(v)
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+	return b
+}
+
+func TestFlattenMsgpack(t *testing.T) {
+	cases := []struct {
+		doc  interface{}
+		want map[string]interface{}
+		err  bool
+	}{
+		// 1
+		{
+			map[string]interface{}{
+				"event": map[string]interface{}{"id": 7, "port": 8080, "big": uint64(1 << 63), "ok": true, "score": 0.5, "raw": []byte{1, 2}},
+				"tags":  []interface{}{"a", nil},
+			},
+			map[string]interface{}{
+				"event.id":    int64(7),
+				"event.port":  int64(8080),
+				"event.big":   uint64(1 << 63),
+				"event.ok":    true,
+				"event.score": 0.5,
+				"event.raw":   []byte{1, 2},
+				"tags.0":      "a",
+				"tags.1":      nil,
+			},
+			false,
+		},
+		// 2 -- integer keys
+		{
+			map[string]interface{}{"codes": map[int]string{200: "ok"}},
+			map[string]interface{}{"codes.200": "ok"},
+			false,
+		},
+		// 3
+		{[]interface{}{1, 2}, nil, true},
+	}
+
+	for i, test := range cases {
+		got, err := FlattenMsgpack(mustMarshal(t, test.doc), "", flatten.DotStyle)
+		if (err != nil) != test.err {
+			t.Errorf("%d: error mismatch, got: [%v]", i+1, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%d: mismatch, got: %#v wanted: %#v", i+1, got, test.want)
+		}
+	}
+
+	if _, err := FlattenMsgpack([]byte{0x81}, "", flatten.DotStyle); err == nil {
+		t.Errorf("truncated input: expected an error")
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	doc := mustMarshal(t, map[string]interface{}{
+		"a": map[string]interface{}{"b": "c", "list": []interface{}{1, 2}},
+	})
+
+	flat, err := FlattenMsgpackBytes(doc, "", flatten.PathStyle)
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+	flatmap, err := decode(flat)
+	if err != nil {
+		t.Fatalf("failed to decode flat: %v", err)
+	}
+	if want := map[string]interface{}{"a/b": "c", "a/list/0": int64(1), "a/list/1": int64(2)}; !reflect.DeepEqual(flatmap, want) {
+		t.Errorf("flat mismatch, got: %#v wanted: %#v", flatmap, want)
+	}
+
+	nested, err := UnflattenMsgpack(flat, flatten.PathStyle)
+	if err != nil {
+		t.Fatalf("failed to unflatten: %v", err)
+	}
+	got, err := FlattenMsgpack(nested, "", flatten.DotStyle)
+	if err != nil {
+		t.Fatalf("failed to flatten again: %v", err)
+	}
+	if want := map[string]interface{}{"a.b": "c", "a.list.0": int64(1), "a.list.1": int64(2)}; !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %v wanted: %v", got, want)
+	}
+}
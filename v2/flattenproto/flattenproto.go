@@ -0,0 +1,16 @@
+// Package flattenproto flattens protocol buffer Structs, google.protobuf.Struct, with the styles of
+// package flatten.
+//
+// It is a module of its own, so that package flatten stays free of the protobuf dependency.
+package flattenproto
+
+import (
+	"github.com/jeremywohl/flatten/v2"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// FlattenStruct generates a flat map from a Struct, walking its values natively: null values are nil,
+// numbers float64, lists slices and Structs maps, as from their JSON mapping.  A nil Struct is empty.
+func FlattenStruct(s *structpb.Struct, prefix string, style flatten.SeparatorStyle) (map[string]interface{}, error) {
+	return flatten.Flatten(s.AsMap(), prefix, style)
+}
@@ -0,0 +1,56 @@
+package flattenproto
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jeremywohl/flatten/v2"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestFlattenStruct(t *testing.T) {
+	s := &structpb.Struct{Fields: map[string]*structpb.Value{
+		"name": structpb.NewStringValue("api"),
+		"port": structpb.NewNumberValue(8080),
+		"tls":  structpb.NewBoolValue(true),
+		"none": structpb.NewNullValue(),
+		"hosts": structpb.NewListValue(&structpb.ListValue{Values: []*structpb.Value{
+			structpb.NewStringValue("a"),
+			structpb.NewStructValue(&structpb.Struct{Fields: map[string]*structpb.Value{
+				"b": structpb.NewNumberValue(1.5),
+			}}),
+		}}),
+		"empty": structpb.NewStructValue(&structpb.Struct{}),
+	}}
+
+	cases := []struct {
+		s    *structpb.Struct
+		want map[string]interface{}
+	}{
+		// 1
+		{
+			s,
+			map[string]interface{}{
+				"name":      "api",
+				"port":      8080.0,
+				"tls":       true,
+				"none":      nil,
+				"hosts.0":   "a",
+				"hosts.1.b": 1.5,
+			},
+		},
+		// 2
+		{nil, map[string]interface{}{}},
+	}
+
+	for i, test := range cases {
+		got, err := FlattenStruct(test.s, "", flatten.DotStyle)
+		if err != nil {
+			t.Errorf("%d: failed to flatten: %v", i+1, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%d: mismatch, got: %v wanted: %v", i+1, got, test.want)
+		}
+	}
+}
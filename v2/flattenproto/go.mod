@@ -0,0 +1,10 @@
+module github.com/jeremywohl/flatten/v2/flattenproto
+
+go 1.23
+
+require (
+	github.com/jeremywohl/flatten/v2 v2.1.0
+	google.golang.org/protobuf v1.36.12
+)
+
+replace github.com/jeremywohl/flatten/v2 => ../
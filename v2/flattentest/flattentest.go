@@ -0,0 +1,95 @@
+// Package flattentest compares nested structures by their flattened form, reporting differences key by key.
+//
+//	func TestConfig(t *testing.T) {
+//		got := loadConfig()
+//		flattentest.AssertEqual(t, want, got)
+//	}
+//
+//	// config_test.go:12: mismatch (-want +got):
+//	//	server.ports.1: got 8443, want 443
+//	//	server.tls: missing, want true
+package flattentest
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/jeremywohl/flatten/v2"
+)
+
+// Diff flattens want and got with DotStyle and reports each key whose value differs, one per line and
+// sorted by key.  It returns an empty string if want and got are equal.
+func Diff(want, got map[string]interface{}) string {
+	wantFlat, err := flatten.Flatten(want, "", flatten.DotStyle)
+	if err != nil {
+		return fmt.Sprintf("cannot flatten want: %v", err)
+	}
+	gotFlat, err := flatten.Flatten(got, "", flatten.DotStyle)
+	if err != nil {
+		return fmt.Sprintf("cannot flatten got: %v", err)
+	}
+
+	keys := make([]string, 0, len(wantFlat)+len(gotFlat))
+	for k := range wantFlat {
+		keys = append(keys, k)
+	}
+	for k := range gotFlat {
+		if _, ok := wantFlat[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var lines []string
+	for _, k := range keys {
+		w, inWant := wantFlat[k]
+		g, inGot := gotFlat[k]
+
+		switch {
+		case !inGot:
+			lines = append(lines, fmt.Sprintf("%s: missing, want %s", k, format(w, g)))
+		case !inWant:
+			lines = append(lines, fmt.Sprintf("%s: unexpected, got %s", k, format(g, w)))
+		case !reflect.DeepEqual(w, g):
+			lines = append(lines, fmt.Sprintf("%s: got %s, want %s", k, format(g, w), format(w, g)))
+		}
+	}
+
+	// Flattening drops empty maps and slices, so the flat forms may agree while the originals do not.
+	if len(lines) == 0 && !reflect.DeepEqual(want, got) {
+		lines = append(lines, fmt.Sprintf("flattened forms are equal, but nested values differ: got %#v, want %#v", got, want))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// AssertEqual reports a test error, with the output of Diff, if want and got are not equal.  It returns
+// whether they were equal.
+func AssertEqual(t testing.TB, want, got map[string]interface{}) bool {
+	t.Helper()
+
+	diff := Diff(want, got)
+	if diff == "" {
+		return true
+	}
+
+	t.Errorf("mismatch (-want +got):\n\t%s", indent(diff))
+	return false
+}
+
+// indent tabs in each line after the first, to nest a multi-line report under its message.
+func indent(s string) string {
+	return strings.ReplaceAll(s, "\n", "\n\t")
+}
+
+// format renders v, with its type when other would otherwise print the same, e.g. int 1 vs float64 1.
+func format(v, other interface{}) string {
+	s := fmt.Sprintf("%#v", v)
+	if reflect.TypeOf(v) != reflect.TypeOf(other) && s == fmt.Sprintf("%#v", other) {
+		s += fmt.Sprintf(" (%T)", v)
+	}
+	return s
+}
@@ -0,0 +1,80 @@
+package flattentest
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	cases := []struct {
+		want map[string]interface{}
+		got  map[string]interface{}
+		diff string
+	}{
+		// 1
+		{
+			map[string]interface{}{"a": map[string]interface{}{"b": "c"}},
+			map[string]interface{}{"a": map[string]interface{}{"b": "c"}},
+			``,
+		},
+		// 2
+		{
+			map[string]interface{}{
+				"a": map[string]interface{}{"b": "c"},
+				"d": []interface{}{1.0, 2.0},
+				"e": true,
+			},
+			map[string]interface{}{
+				"a": map[string]interface{}{"b": "x"},
+				"d": []interface{}{1.0},
+				"f": "new",
+			},
+			`a.b: got "x", want "c"
+			d.1: missing, want 2
+			e: missing, want true
+			f: unexpected, got "new"`,
+		},
+		// 3 -- same printed value, different types
+		{
+			map[string]interface{}{"a": 1},
+			map[string]interface{}{"a": 1.0},
+			`a: got 1 (float64), want 1 (int)`,
+		},
+		// 4 -- empty containers vanish when flattened
+		{
+			map[string]interface{}{"a": map[string]interface{}{}},
+			map[string]interface{}{},
+			`flattened forms are equal, but nested values differ: got map[string]interface {}{}, want map[string]interface {}{"a":map[string]interface {}{}}`,
+		},
+	}
+
+	for i, test := range cases {
+		want := strings.ReplaceAll(test.diff, "\n\t\t\t", "\n")
+		if got := Diff(test.want, test.got); got != want {
+			t.Errorf("%d: mismatch, got:\n%s\nwanted:\n%s", i+1, got, want)
+		}
+	}
+}
+
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, format)
+}
+
+func TestAssertEqual(t *testing.T) {
+	r := &recorder{TB: t}
+	if !AssertEqual(r, map[string]interface{}{"a": "b"}, map[string]interface{}{"a": "b"}) || len(r.errors) != 0 {
+		t.Errorf("1: equal maps reported as unequal: %v", r.errors)
+	}
+
+	r = &recorder{TB: t}
+	if AssertEqual(r, map[string]interface{}{"a": "b"}, map[string]interface{}{"a": "c"}) || len(r.errors) != 1 {
+		t.Errorf("2: unequal maps reported as equal")
+	}
+}
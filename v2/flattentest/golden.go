@@ -0,0 +1,86 @@
+package flattentest
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jeremywohl/flatten/v2"
+)
+
+var update = flag.Bool("flattentest.update", false, "rewrite golden files with the current results")
+
+// Snapshot renders the canonical flat form of nested: its DotStyle flattening as indented JSON, sorted
+// by key, one key per line.  It is stable from run to run, and reviews well in a diff.
+func Snapshot(nested map[string]interface{}) ([]byte, error) {
+	flat, err := flatten.Flatten(nested, "", flatten.DotStyle)
+	if err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(flat); err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+// AssertGolden compares the Snapshot of got with the golden file at path, conventionally under
+// testdata/, reporting a test error key by key if they differ.  It returns whether they were equal.
+//
+// Run the tests with -flattentest.update to write the golden files from the current results instead.
+func AssertGolden(t testing.TB, path string, got map[string]interface{}) bool {
+	t.Helper()
+
+	snap, err := Snapshot(got)
+	if err != nil {
+		t.Errorf("cannot snapshot: %v", err)
+		return false
+	}
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Errorf("cannot update golden file: %v", err)
+			return false
+		}
+		if err := os.WriteFile(path, snap, 0644); err != nil {
+			t.Errorf("cannot update golden file: %v", err)
+			return false
+		}
+		return true
+	}
+
+	golden, err := os.ReadFile(path)
+	if err != nil {
+		t.Errorf("cannot read golden file (run with -flattentest.update to create it): %v", err)
+		return false
+	}
+	if bytes.Equal(golden, snap) {
+		return true
+	}
+
+	// Compare decoded forms, so both sides carry the same (JSON) types.
+	var want, have map[string]interface{}
+	if err := json.Unmarshal(golden, &want); err != nil {
+		t.Errorf("cannot parse golden file %s: %v", path, err)
+		return false
+	}
+	if err := json.Unmarshal(snap, &have); err != nil {
+		t.Errorf("cannot parse snapshot: %v", err)
+		return false
+	}
+
+	diff := Diff(want, have)
+	if diff == "" {
+		diff = "formatting differs; run with -flattentest.update to rewrite it"
+	}
+	t.Errorf("mismatch with golden file %s (-want +got):\n\t%s", path, indent(diff))
+	return false
+}
@@ -0,0 +1,63 @@
+package flattentest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSnapshot(t *testing.T) {
+	got, err := Snapshot(map[string]interface{}{
+		"b": []interface{}{1, "<x>"},
+		"a": map[string]interface{}{"c": true, "b": nil},
+	})
+	if err != nil {
+		t.Fatalf("failed to snapshot: %v", err)
+	}
+	want := `{
+  "a.b": null,
+  "a.c": true,
+  "b.0": 1,
+  "b.1": "<x>"
+}
+`
+	if string(got) != want {
+		t.Errorf("mismatch, got:\n%s\nwanted:\n%s", got, want)
+	}
+}
+
+func TestAssertGolden(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "testdata", "config.golden")
+	nested := map[string]interface{}{"server": map[string]interface{}{"port": 443}}
+
+	// 1 -- no golden file yet
+	r := &recorder{TB: t}
+	if AssertGolden(r, path, nested) || len(r.errors) != 1 {
+		t.Errorf("1: missing golden file not reported")
+	}
+
+	// 2 -- write it
+	*update = true
+	r = &recorder{TB: t}
+	ok := AssertGolden(r, path, nested)
+	*update = false
+	if !ok || len(r.errors) != 0 {
+		t.Errorf("2: update failed: %v", r.errors)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("2: golden file not written: %v", err)
+	}
+
+	// 3 -- compare with it
+	r = &recorder{TB: t}
+	if !AssertGolden(r, path, nested) || len(r.errors) != 0 {
+		t.Errorf("3: equal snapshot reported as unequal: %v", r.errors)
+	}
+
+	// 4
+	r = &recorder{TB: t}
+	changed := map[string]interface{}{"server": map[string]interface{}{"port": 8443}}
+	if AssertGolden(r, path, changed) || len(r.errors) != 1 {
+		t.Errorf("4: changed snapshot reported as equal")
+	}
+}
@@ -0,0 +1,30 @@
+// Package flattentoml flattens TOML documents with the styles of package flatten.
+//
+// It is a module of its own, so that package flatten stays free of the TOML dependency.
+package flattentoml
+
+import (
+	"io"
+	"strings"
+
+	"github.com/jeremywohl/flatten/v2"
+	"github.com/pelletier/go-toml/v2"
+)
+
+// FlattenTOML generates a flat map from the TOML document read from r.  Tables are maps and arrays,
+// arrays of tables included, are slices, indexed as any; integers are int64 and floats float64.
+// Offset date-times are strings in RFC 3339, as Flatten renders time.Time; local dates and times are
+// strings of their TOML text, e.g. "2024-05-01" or "07:32:00".
+func FlattenTOML(r io.Reader, prefix string, style flatten.SeparatorStyle) (map[string]interface{}, error) {
+	var nested map[string]interface{}
+	if err := toml.NewDecoder(r).Decode(&nested); err != nil {
+		return nil, err
+	}
+
+	return flatten.Flatten(nested, prefix, style)
+}
+
+// FlattenTOMLString is FlattenTOML, reading from a string.
+func FlattenTOMLString(tomlstr, prefix string, style flatten.SeparatorStyle) (map[string]interface{}, error) {
+	return FlattenTOML(strings.NewReader(tomlstr), prefix, style)
+}
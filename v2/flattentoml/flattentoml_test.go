@@ -0,0 +1,98 @@
+package flattentoml
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jeremywohl/flatten/v2"
+)
+
+func TestFlattenTOMLString(t *testing.T) {
+	cases := []struct {
+		src  string
+		want map[string]interface{}
+		err  bool
+	}{
+		// 1
+		{
+			`
+title = "app"
+
+[server]
+host = "localhost"
+port = 8080
+ratio = 0.5
+tls = true
+tags = ["a", "b"]
+`,
+			map[string]interface{}{
+				"title":         "app",
+				"server.host":   "localhost",
+				"server.port":   int64(8080),
+				"server.ratio":  0.5,
+				"server.tls":    true,
+				"server.tags.0": "a",
+				"server.tags.1": "b",
+			},
+			false,
+		},
+		// 2 -- arrays of tables
+		{
+			`
+[[products]]
+name = "hammer"
+
+[[products]]
+name = "nail"
+sizes = [1, 2]
+
+[products.vendor]
+"co.ltd" = "acme"
+`,
+			map[string]interface{}{
+				"products.0.name":          "hammer",
+				"products.1.name":          "nail",
+				"products.1.sizes.0":       int64(1),
+				"products.1.sizes.1":       int64(2),
+				"products.1.vendor.co.ltd": "acme",
+			},
+			false,
+		},
+		// 3
+		{`a = `, nil, true},
+	}
+
+	for i, test := range cases {
+		got, err := FlattenTOMLString(test.src, "", flatten.DotStyle)
+		if (err != nil) != test.err {
+			t.Errorf("%d: error mismatch, got: [%v]", i+1, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%d: mismatch, got: %v wanted: %v", i+1, got, test.want)
+		}
+	}
+}
+
+func TestFlattenTOMLDateTime(t *testing.T) {
+	got, err := FlattenTOMLString("[build]\nat = 2024-05-01T10:00:00+02:00\n", "", flatten.PathStyle)
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+
+	want := map[string]interface{}{"build/at": "2024-05-01T10:00:00+02:00"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %v wanted: %v", got, want)
+	}
+
+	// Local dates and times, as their TOML text
+	got, err = FlattenTOMLString("day = 2024-05-01\nclock = 07:32:00\nlocal = 2024-05-01T07:32:00\n", "", flatten.DotStyle)
+	if err != nil {
+		t.Fatalf("failed to flatten local: %v", err)
+	}
+
+	want = map[string]interface{}{"day": "2024-05-01", "clock": "07:32:00", "local": "2024-05-01T07:32:00"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("local mismatch, got: %#v wanted: %#v", got, want)
+	}
+}
@@ -0,0 +1,10 @@
+module github.com/jeremywohl/flatten/v2/flattentoml
+
+go 1.21.0
+
+require (
+	github.com/jeremywohl/flatten/v2 v2.1.0
+	github.com/pelletier/go-toml/v2 v2.3.1
+)
+
+replace github.com/jeremywohl/flatten/v2 => ../
@@ -0,0 +1,73 @@
+// Package flattenyaml flattens YAML documents with the styles of package flatten.
+//
+// It is a module of its own, so that package flatten stays free of the YAML dependency.
+package flattenyaml
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jeremywohl/flatten/v2"
+	"go.yaml.in/yaml/v3"
+)
+
+// Flatten generates a flat map from a decoded YAML document, as from yaml.Unmarshal into an
+// interface{}.  Maps with keys of any scalar type, map[interface{}]interface{} as YAML decoders produce,
+// are flattened as if their keys were strings, e.g. 1 as "1" and true as "true".
+func Flatten(doc interface{}, prefix string, style flatten.SeparatorStyle) (map[string]interface{}, error) {
+	m, ok := normalize(doc).(map[string]interface{})
+	if !ok {
+		return nil, flatten.NotValidInputError
+	}
+
+	return flatten.Flatten(m, prefix, style)
+}
+
+// FlattenYAML generates a flat map from the first YAML document read from r, which must be a map.
+// Multi-line scalars, literal or folded, are single string values.  An empty document is an empty map.
+func FlattenYAML(r io.Reader, prefix string, style flatten.SeparatorStyle) (map[string]interface{}, error) {
+	var doc interface{}
+	if err := yaml.NewDecoder(r).Decode(&doc); err != nil && err != io.EOF {
+		return nil, err
+	}
+	if doc == nil {
+		return map[string]interface{}{}, nil
+	}
+
+	return Flatten(doc, prefix, style)
+}
+
+// FlattenYAMLString is FlattenYAML, reading from a string.
+func FlattenYAMLString(yamlstr, prefix string, style flatten.SeparatorStyle) (map[string]interface{}, error) {
+	return FlattenYAML(strings.NewReader(yamlstr), prefix, style)
+}
+
+// normalize converts, depth first, maps keyed by interface{} into maps keyed by string.
+func normalize(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, child := range v {
+			m[keyString(k)] = normalize(child)
+		}
+		return m
+	case map[string]interface{}:
+		for k, child := range v {
+			v[k] = normalize(child)
+		}
+	case []interface{}:
+		for i, child := range v {
+			v[i] = normalize(child)
+		}
+	}
+
+	return v
+}
+
+func keyString(k interface{}) string {
+	if k == nil {
+		return "null"
+	}
+	return fmt.Sprint(k)
+}
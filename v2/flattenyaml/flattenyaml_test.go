@@ -0,0 +1,127 @@
+package flattenyaml
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/jeremywohl/flatten/v2"
+)
+
+func TestFlattenYAMLString(t *testing.T) {
+	cases := []struct {
+		src  string
+		want map[string]interface{}
+		err  error
+	}{
+		// 1
+		{
+			`
+app:
+  name: api
+  port: 8080
+  ratio: 1.5
+  debug: false
+  hosts: [a, b]
+  none: ~
+`,
+			map[string]interface{}{
+				"app.name":    "api",
+				"app.port":    8080,
+				"app.ratio":   1.5,
+				"app.debug":   false,
+				"app.hosts.0": "a",
+				"app.hosts.1": "b",
+				"app.none":    nil,
+			},
+			nil,
+		},
+		// 2 -- multi-line scalars
+		{
+			`
+literal: |
+  line one
+  line two
+folded: >
+  one
+  two
+`,
+			map[string]interface{}{
+				"literal": "line one\nline two\n",
+				"folded":  "one two\n",
+			},
+			nil,
+		},
+		// 3 -- non-string keys
+		{
+			`
+codes:
+  200: ok
+  404: missing
+flags:
+  true: yes
+  ~: nothing
+`,
+			map[string]interface{}{
+				"codes.200":  "ok",
+				"codes.404":  "missing",
+				"flags.true": "yes",
+				"flags.null": "nothing",
+			},
+			nil,
+		},
+		// 4 -- anchors and merge keys
+		{
+			`
+base: &base {a: 1}
+derived:
+  <<: *base
+  b: 2
+`,
+			map[string]interface{}{
+				"base.a":    1,
+				"derived.a": 1,
+				"derived.b": 2,
+			},
+			nil,
+		},
+		// 5
+		{``, map[string]interface{}{}, nil},
+		// 6
+		{`[1, 2]`, nil, flatten.NotValidInputError},
+	}
+
+	for i, test := range cases {
+		got, err := FlattenYAMLString(test.src, "", flatten.DotStyle)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%d: error mismatch, got: [%v], wanted: [%v]", i+1, err, test.err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%d: mismatch, got: %v wanted: %v", i+1, got, test.want)
+		}
+	}
+}
+
+func TestFlatten(t *testing.T) {
+	// As decoded by yaml.v2, with interface{} keys throughout
+	doc := map[interface{}]interface{}{
+		"a": map[interface{}]interface{}{
+			1:   "one",
+			"b": []interface{}{map[interface{}]interface{}{"c": true}},
+		},
+	}
+
+	got, err := Flatten(doc, "", flatten.RailsStyle)
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+
+	want := map[string]interface{}{
+		"a[1]":       "one",
+		"a[b][0][c]": true,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %v wanted: %v", got, want)
+	}
+}
@@ -0,0 +1,10 @@
+module github.com/jeremywohl/flatten/v2/flattenyaml
+
+go 1.21
+
+require (
+	github.com/jeremywohl/flatten/v2 v2.1.0
+	go.yaml.in/yaml/v3 v3.0.4
+)
+
+replace github.com/jeremywohl/flatten/v2 => ../
@@ -0,0 +1,56 @@
+package flatten
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// NewFormRequest returns a request whose body is nested, flattened with RailsStyle and URL-encoded, as a
+// browser would submit an HTML form, e.g. "user[name]=jim&user[tags][0]=a".
+func NewFormRequest(method, url string, nested map[string]interface{}) (*http.Request, error) {
+	values, err := flatValues(nested, "", RailsStyle)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(method, url, strings.NewReader(values.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	return req, nil
+}
+
+// FlattenToQuery flattens nested into URL query values, one per key, e.g. user[tags][0]=a with
+// RailsStyle, as Rails and PHP parse them back.
+func FlattenToQuery(nested map[string]interface{}, style SeparatorStyle) (url.Values, error) {
+	return flatValues(nested, "", style)
+}
+
+// FlattenToQueryString is FlattenToQuery, encoded as a query string, sorted by key, e.g.
+// "user%5Bname%5D=jim&user%5Btags%5D%5B0%5D=a".
+func FlattenToQueryString(nested map[string]interface{}, style SeparatorStyle) (string, error) {
+	values, err := flatValues(nested, "", style)
+	if err != nil {
+		return "", err
+	}
+
+	return values.Encode(), nil
+}
+
+// flatValues flattens nested into form values, one per key.
+func flatValues(nested map[string]interface{}, prefix string, style SeparatorStyle) (url.Values, error) {
+	flat, err := Flatten(nested, prefix, style)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(url.Values, len(flat))
+	for k, v := range flat {
+		values.Set(k, formatValue(v))
+	}
+
+	return values, nil
+}
@@ -0,0 +1,81 @@
+package flatten
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestNewFormRequest(t *testing.T) {
+	nested := map[string]interface{}{
+		"user": map[string]interface{}{
+			"name": "jim bean",
+			"tags": []interface{}{"a", "b"},
+		},
+		"count": 2.0,
+	}
+
+	req, err := NewFormRequest(http.MethodPost, "http://example.com/users", nested)
+	if err != nil {
+		t.Fatalf("failed to build request: %v", err)
+	}
+
+	if got := req.Header.Get("Content-Type"); got != "application/x-www-form-urlencoded" {
+		t.Errorf("content type mismatch, got: %v", got)
+	}
+
+	if err := req.ParseForm(); err != nil {
+		t.Fatalf("failed to parse form: %v", err)
+	}
+	want := map[string][]string{
+		"count":         {"2"},
+		"user[name]":    {"jim bean"},
+		"user[tags][0]": {"a"},
+		"user[tags][1]": {"b"},
+	}
+	if !reflect.DeepEqual(map[string][]string(req.PostForm), want) {
+		t.Errorf("form mismatch, got: %v wanted: %v", req.PostForm, want)
+	}
+}
+
+func TestNewFormRequestBadURL(t *testing.T) {
+	if _, err := NewFormRequest(http.MethodPost, "://nohost", map[string]interface{}{}); err == nil {
+		t.Errorf("expected an error for a malformed URL")
+	}
+}
+
+func TestFlattenToQuery(t *testing.T) {
+	nested := map[string]interface{}{
+		"q":    "a&b",
+		"page": map[string]interface{}{"size": 10.0, "sort": []interface{}{"name", nil}},
+	}
+
+	cases := []struct {
+		style SeparatorStyle
+		want  string
+	}{
+		// 1
+		{RailsStyle, "page%5Bsize%5D=10&page%5Bsort%5D%5B0%5D=name&page%5Bsort%5D%5B1%5D=&q=a%26b"},
+		// 2
+		{DotStyle, "page.size=10&page.sort.0=name&page.sort.1=&q=a%26b"},
+	}
+
+	for i, test := range cases {
+		got, err := FlattenToQueryString(nested, test.style)
+		if err != nil {
+			t.Errorf("%d: failed to flatten: %v", i+1, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%d: mismatch, got: %v wanted: %v", i+1, got, test.want)
+		}
+	}
+
+	values, err := FlattenToQuery(nested, RailsStyle)
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+	if got := values.Get("page[sort][0]"); got != "name" {
+		t.Errorf("value mismatch, got: %v wanted: name", got)
+	}
+}
@@ -0,0 +1,70 @@
+package flatten
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// sortedKeys returns the keys of a flat map in order, so text outputs are stable from run to run.
+func sortedKeys(flat map[string]interface{}) []string {
+	keys := make([]string, 0, len(flat))
+	for k := range flat {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// formatValue renders a flattened leaf as text, for the output formats which are not JSON.  Numbers are
+// printed in their shortest exact form (1.5, not 1.500000e+00) and nil becomes the empty string.
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// A ValueEncoder renders a flattened leaf as bytes, for outputs which carry values as bytes.
+type ValueEncoder func(v interface{}) ([]byte, error)
+
+// TextValue encodes a leaf as plain text: strings as they are, numbers in their shortest exact form,
+// nil as empty.
+func TextValue(v interface{}) ([]byte, error) {
+	return []byte(formatValue(v)), nil
+}
+
+// JSONValue encodes a leaf as JSON, keeping strings distinct from numbers, booleans and null.
+func JSONValue(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// A ValueDecoder parses a leaf from bytes, reversing a ValueEncoder.
+type ValueDecoder func(b []byte) (interface{}, error)
+
+// ParseTextValue decodes a leaf as a string, reversing TextValue for strings.
+func ParseTextValue(b []byte) (interface{}, error) {
+	return string(b), nil
+}
+
+// ParseJSONValue decodes a leaf from JSON, reversing JSONValue.
+func ParseJSONValue(b []byte) (interface{}, error) {
+	var v interface{}
+	err := json.Unmarshal(b, &v)
+	return v, err
+}
@@ -0,0 +1,57 @@
+package flatten
+
+import (
+	"testing"
+)
+
+func TestFormatValue(t *testing.T) {
+	cases := []struct {
+		value interface{}
+		want  string
+	}{
+		// 1
+		{"text", "text"},
+		// 2
+		{1.4567, "1.4567"},
+		// 3 -- no exponent for large whole numbers
+		{1e21, "1000000000000000000000"},
+		// 4
+		{true, "true"},
+		// 5
+		{nil, ""},
+		// 6
+		{[]byte("raw"), "raw"},
+		// 7
+		{42, "42"},
+	}
+
+	for i, test := range cases {
+		if got := formatValue(test.value); got != test.want {
+			t.Errorf("%d: mismatch, got: %v wanted: %v", i+1, got, test.want)
+		}
+	}
+}
+
+func TestValueEncoders(t *testing.T) {
+	cases := []struct {
+		value interface{}
+		text  string
+		json  string
+	}{
+		// 1
+		{"s", "s", `"s"`},
+		// 2
+		{1.5, "1.5", "1.5"},
+		// 3
+		{nil, "", "null"},
+	}
+
+	for i, test := range cases {
+		if got, _ := TextValue(test.value); string(got) != test.text {
+			t.Errorf("%d: text mismatch, got: %s wanted: %s", i+1, got, test.text)
+		}
+		if got, _ := JSONValue(test.value); string(got) != test.json {
+			t.Errorf("%d: json mismatch, got: %s wanted: %s", i+1, got, test.json)
+		}
+	}
+}
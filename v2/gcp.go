@@ -0,0 +1,77 @@
+package flatten
+
+import (
+	"unicode/utf8"
+)
+
+// Limits of Google Cloud Logging on the labels of a log entry.
+const (
+	GCPMaxLabels        = 64
+	GCPMaxLabelKeyLen   = 512       // bytes
+	GCPMaxLabelValueLen = 64 * 1024 // bytes
+)
+
+// A LimitReport lists the keys which a flattener for a limited target dropped or truncated.
+type LimitReport struct {
+	Dropped   []string // Keys left out, as flattened
+	Truncated []string // Keys whose key or value was cut short, as flattened
+}
+
+// GCPLabels flattens nested with DotStyle into Google Cloud Logging labels, within its limits: keys
+// and values cut to length, on character boundaries, and at most GCPMaxLabels labels, keeping the
+// first in key order.  A key which collides with another once truncated is dropped.  The report lists
+// what did not survive intact.
+func GCPLabels(nested map[string]interface{}) (map[string]string, LimitReport, error) {
+	var report LimitReport
+
+	flat, err := Flatten(nested, "", DotStyle)
+	if err != nil {
+		return nil, report, err
+	}
+
+	labels := make(map[string]string)
+	for _, k := range sortedKeys(flat) {
+		if len(labels) == GCPMaxLabels {
+			report.Dropped = append(report.Dropped, k)
+			continue
+		}
+
+		key, cutKey := truncateUTF8(k, GCPMaxLabelKeyLen)
+		value, cutValue := truncateUTF8(formatValue(flat[k]), GCPMaxLabelValueLen)
+
+		if _, ok := labels[key]; ok {
+			report.Dropped = append(report.Dropped, k)
+			continue
+		}
+		if cutKey || cutValue {
+			report.Truncated = append(report.Truncated, k)
+		}
+		labels[key] = value
+	}
+
+	return labels, report, nil
+}
+
+// truncateUTF8 cuts s to at most n bytes, without splitting a character, and reports whether it did.
+func truncateUTF8(s string, n int) (string, bool) {
+	if len(s) <= n {
+		return s, false
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n], true
+}
+
+// ellipsize cuts s, if longer, to n bytes ending in ellipsis.
+func ellipsize(s string, n int, ellipsis string) string {
+	if len(s) <= n {
+		return s
+	}
+	if n <= len(ellipsis) {
+		s, _ = truncateUTF8(s, n)
+		return s
+	}
+	s, _ = truncateUTF8(s, n-len(ellipsis))
+	return s + ellipsis
+}
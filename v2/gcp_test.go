@@ -0,0 +1,83 @@
+package flatten
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGCPLabels(t *testing.T) {
+	nested := map[string]interface{}{
+		"http": map[string]interface{}{
+			"status": 200.0,
+			"method": "GET",
+		},
+		"body": strings.Repeat("x", GCPMaxLabelValueLen+1),
+	}
+
+	got, report, err := GCPLabels(nested)
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+
+	want := map[string]string{
+		"http.status": "200",
+		"http.method": "GET",
+		"body":        strings.Repeat("x", GCPMaxLabelValueLen),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %v wanted: %v", got, want)
+	}
+	if want := (LimitReport{Truncated: []string{"body"}}); !reflect.DeepEqual(report, want) {
+		t.Errorf("report mismatch, got: %v wanted: %v", report, want)
+	}
+}
+
+func TestGCPLabelsLimits(t *testing.T) {
+	nested := make(map[string]interface{})
+	for i := 0; i < GCPMaxLabels+2; i++ {
+		nested[fmt.Sprintf("k%03d", i)] = "v"
+	}
+
+	long := strings.Repeat("a", GCPMaxLabelKeyLen)
+	nested["0"] = map[string]interface{}{long + "1": "x", long + "2": "y"}
+
+	got, report, err := GCPLabels(nested)
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+
+	if len(got) != GCPMaxLabels {
+		t.Errorf("count mismatch, got: %d wanted: %d", len(got), GCPMaxLabels)
+	}
+	// "0.aaa...1" is cut to 512 bytes, so "0.aaa...2" collides; two more fall past the limit.
+	wantReport := LimitReport{
+		Dropped:   []string{"0." + long + "2", "k063", "k064", "k065"},
+		Truncated: []string{"0." + long + "1"},
+	}
+	if !reflect.DeepEqual(report, wantReport) {
+		t.Errorf("report mismatch, got: %v wanted: %v", report, wantReport)
+	}
+}
+
+func TestTruncateUTF8(t *testing.T) {
+	cases := []struct {
+		s    string
+		n    int
+		want string
+		cut  bool
+	}{
+		{"abc", 3, "abc", false},
+		{"abcd", 3, "abc", true},
+		{"aé", 2, "a", true},
+		{"éé", 3, "é", true},
+	}
+
+	for i, test := range cases {
+		got, cut := truncateUTF8(test.s, test.n)
+		if got != test.want || cut != test.cut {
+			t.Errorf("%d: mismatch, got: %q, %v wanted: %q, %v", i+1, got, cut, test.want, test.cut)
+		}
+	}
+}
@@ -1 +1,3 @@
 module github.com/jeremywohl/flatten/v2
+
+go 1.21
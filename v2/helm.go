@@ -0,0 +1,62 @@
+package flatten
+
+import (
+	"strconv"
+	"strings"
+)
+
+// HelmStyle addresses Helm chart values as --set does, e.g. "a.b[0].c", with dots, brackets and the
+// other characters meaningful to --set escaped by a backslash within keys, e.g. `nodeSelector.kubernetes\.io/os`.
+var HelmStyle = SeparatorStyle{Middle: ".", IndexBefore: "[", IndexAfter: "]", Escaper: BackslashEscaper(".[]=,")}
+
+// HelmSetArgs returns helm command-line arguments which set each leaf of values, in key order, e.g.
+//
+//	--set image.tag=1.2 --set ingress.hosts[0]=example.com --set-string build.id=123
+//
+// Commas in values are escaped, as are braces, which would otherwise start a list.  Strings which helm
+// would read as another type, e.g. "true", "null" or "123", are passed with --set-string instead.
+func HelmSetArgs(values map[string]interface{}) ([]string, error) {
+	flat, err := Flatten(values, "", HelmStyle)
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, 0, 2*len(flat))
+	for _, k := range sortedKeys(flat) {
+		flag := "--set"
+		v := flat[k]
+
+		s := formatValue(v)
+		switch v.(type) {
+		case nil:
+			s = "null"
+		case string:
+			if helmCoerces(s) {
+				flag = "--set-string"
+			}
+		}
+
+		args = append(args, flag, k+"="+helmValueEscaper.Escape(s))
+	}
+
+	return args, nil
+}
+
+var helmValueEscaper = BackslashEscaper(",{}")
+
+// helmCoerces reports whether helm's --set would read s as something other than a string.  Like helm, it
+// reads true, false and null in any case, e.g. "True".
+func helmCoerces(s string) bool {
+	for _, word := range []string{"true", "false", "null"} {
+		if strings.EqualFold(s, word) {
+			return true
+		}
+	}
+	if s == "0" {
+		return true
+	}
+	if _, err := strconv.ParseInt(s, 10, 64); err == nil && !strings.HasPrefix(s, "0") {
+		return true
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package flatten
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHelmSetArgs(t *testing.T) {
+	cases := []struct {
+		values map[string]interface{}
+		want   []string
+	}{
+		// 1
+		{
+			map[string]interface{}{
+				"image": map[string]interface{}{"tag": "1.2", "pullPolicy": "Always"},
+				"ingress": map[string]interface{}{
+					"hosts": []interface{}{map[string]interface{}{"host": "example.com"}},
+				},
+				"replicas": 3.0,
+			},
+			[]string{
+				"--set", "image.pullPolicy=Always",
+				"--set", "image.tag=1.2",
+				"--set", "ingress.hosts[0].host=example.com",
+				"--set", "replicas=3",
+			},
+		},
+		// 2 -- escaping in keys and values
+		{
+			map[string]interface{}{
+				"nodeSelector": map[string]interface{}{"kubernetes.io/os": "linux"},
+				"list":         "a,b",
+				"braces":       "{x}",
+				`back\slash`:   `c:\dir`,
+			},
+			[]string{
+				"--set", `back\\slash=c:\\dir`,
+				"--set", `braces=\{x\}`,
+				"--set", `list=a\,b`,
+				"--set", `nodeSelector.kubernetes\.io/os=linux`,
+			},
+		},
+		// 3 -- strings which helm would coerce
+		{
+			map[string]interface{}{
+				"a": "true",
+				"b": "123",
+				"c": "0123",
+				"d": true,
+				"e": nil,
+				"f": "null",
+				"g": "True",
+				"h": "NULL",
+			},
+			[]string{
+				"--set-string", "a=true",
+				"--set-string", "b=123",
+				"--set", "c=0123",
+				"--set", "d=true",
+				"--set", "e=null",
+				"--set-string", "f=null",
+				"--set-string", "g=True",
+				"--set-string", "h=NULL",
+			},
+		},
+	}
+
+	for i, test := range cases {
+		got, err := HelmSetArgs(test.values)
+		if err != nil {
+			t.Errorf("%d: failed to build args: %v", i+1, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%d: mismatch, got: %q wanted: %q", i+1, got, test.want)
+		}
+	}
+}
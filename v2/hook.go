@@ -0,0 +1,50 @@
+package flatten
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// CallStats describe one call, for instrumentation.
+type CallStats struct {
+	Op        string // The function called, e.g. "Flatten" or "FlattenString"
+	InputSize int    // Bytes of JSON text input, or members of the top-level input map or slice
+	Keys      int    // Keys in the result
+	Duration  time.Duration
+	Err       error
+}
+
+var hook atomic.Value // func(op string) func(CallStats)
+
+// SetHook installs h, to be called as each flattening call starts.  If h returns a function, it is
+// called as the call ends, with its stats.  This suits starting and ending a tracing span per call:
+//
+//	flatten.SetHook(func(op string) func(flatten.CallStats) {
+//		_, span := tracer.Start(ctx, "flatten."+op)
+//		return func(s flatten.CallStats) {
+//			span.SetAttributes(attribute.Int("flatten.keys", s.Keys))
+//			span.End()
+//		}
+//	})
+//
+// A nil h removes the hook.  The hook is shared by all goroutines, so it must be safe for concurrent use.
+func SetHook(h func(op string) func(CallStats)) {
+	hook.Store(h)
+}
+
+// observe starts observing a call to op, returning the function to end it.
+func observe(op string, size int) func(keys int, err error) {
+	h, _ := hook.Load().(func(string) func(CallStats))
+	if h == nil {
+		return func(int, error) {}
+	}
+	done := h(op)
+	if done == nil {
+		return func(int, error) {}
+	}
+
+	start := time.Now()
+	return func(keys int, err error) {
+		done(CallStats{Op: op, InputSize: size, Keys: keys, Duration: time.Since(start), Err: err})
+	}
+}
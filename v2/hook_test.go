@@ -0,0 +1,43 @@
+package flatten
+
+import (
+	"testing"
+)
+
+func TestSetHook(t *testing.T) {
+	var started []string
+	var ended []CallStats
+	SetHook(func(op string) func(CallStats) {
+		started = append(started, op)
+		return func(s CallStats) { ended = append(ended, s) }
+	})
+	defer SetHook(nil)
+
+	if _, err := FlattenString(`{ "a": { "b": 1, "c": 2 } }`, "", DotStyle); err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+	if _, err := FlattenString(`[]`, "", DotStyle); err != NotValidJsonInputError {
+		t.Fatalf("error mismatch, got: [%v], wanted: [%v]", err, NotValidJsonInputError)
+	}
+	if _, err := Flatten(map[string]interface{}{"a": "b"}, "", DotStyle); err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+
+	if len(started) != 3 || started[0] != "FlattenString" || started[2] != "Flatten" {
+		t.Fatalf("mismatch, got: %v wanted: [FlattenString FlattenString Flatten]", started)
+	}
+	if s := ended[0]; s.Op != "FlattenString" || s.InputSize != 27 || s.Keys != 2 || s.Err != nil {
+		t.Errorf("1: mismatch, got: %+v", s)
+	}
+	if s := ended[1]; s.Err != NotValidJsonInputError {
+		t.Errorf("2: error mismatch, got: [%v], wanted: [%v]", s.Err, NotValidJsonInputError)
+	}
+	if s := ended[2]; s.InputSize != 1 || s.Keys != 1 {
+		t.Errorf("3: mismatch, got: %+v", s)
+	}
+
+	SetHook(nil)
+	if _, err := Flatten(map[string]interface{}{"a": "b"}, "", DotStyle); err != nil || len(started) != 3 {
+		t.Errorf("hook called after removal")
+	}
+}
@@ -0,0 +1,35 @@
+package flatten
+
+import (
+	"sync"
+)
+
+// An Interner keeps one copy of each string it is given, so flat maps sharing it share their repeated
+// strings rather than each holding its own.  It may be shared by concurrent flattenings.  The zero value
+// is ready to use; it grows without bound, so scope it to a batch of related documents.
+type Interner struct {
+	mu      sync.Mutex
+	strings map[string]string
+}
+
+// Intern returns the kept copy of s, keeping s if there is none.
+func (in *Interner) Intern(s string) string {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	if kept, ok := in.strings[s]; ok {
+		return kept
+	}
+	if in.strings == nil {
+		in.strings = make(map[string]string)
+	}
+	in.strings[s] = s
+	return s
+}
+
+// Len returns the number of strings kept.
+func (in *Interner) Len() int {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	return len(in.strings)
+}
@@ -0,0 +1,74 @@
+package flatten
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+func TestInterner(t *testing.T) {
+	var in Interner
+	a := in.Intern(strings.Repeat("x", 3))
+	b := in.Intern(strings.Repeat("x", 3))
+	if a != b || unsafe.StringData(a) != unsafe.StringData(b) {
+		t.Errorf("repeated string not shared")
+	}
+	if in.Len() != 1 {
+		t.Errorf("mismatch, got: %d wanted: %d", in.Len(), 1)
+	}
+}
+
+func TestInternValues(t *testing.T) {
+	in := &Interner{}
+	opts := Options{Interner: in, InternKeys: true}
+
+	var flats []map[string]interface{}
+	for i := 0; i < 3; i++ {
+		nested := map[string]interface{}{
+			"level": strings.ToLower("INFO"),
+			"host":  map[string]interface{}{"name": strings.ToLower("WEB-1")},
+			"n":     float64(i),
+		}
+		flat, err := opts.Flatten(nested, "", DotStyle)
+		if err != nil {
+			t.Fatalf("failed to flatten: %v", err)
+		}
+		flats = append(flats, flat)
+	}
+
+	want := map[string]interface{}{"level": "info", "host.name": "web-1", "n": 2.0}
+	if !reflect.DeepEqual(flats[2], want) {
+		t.Errorf("mismatch, got: %v wanted: %v", flats[2], want)
+	}
+	if s0, s2 := flats[0]["level"].(string), flats[2]["level"].(string); unsafe.StringData(s0) != unsafe.StringData(s2) {
+		t.Errorf("values not shared")
+	}
+
+	// 2 values and 3 keys
+	if in.Len() != 5 {
+		t.Errorf("mismatch, got: %d wanted: %d", in.Len(), 5)
+	}
+}
+
+func TestInternTruncated(t *testing.T) {
+	in := &Interner{}
+	opts := Options{Interner: in, MaxValueLen: 5}
+
+	nested := map[string]interface{}{"a": strings.Repeat("x", 100), "b": strings.Repeat("x", 200)}
+	flat, err := opts.Flatten(nested, "", DotStyle)
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+
+	want := map[string]interface{}{"a": "xx...", "b": "xx..."}
+	if !reflect.DeepEqual(flat, want) {
+		t.Errorf("mismatch, got: %v wanted: %v", flat, want)
+	}
+	if in.Len() != 1 {
+		t.Errorf("mismatch, got: %d wanted: %d", in.Len(), 1)
+	}
+	if in.Intern("xx..."); in.Len() != 1 {
+		t.Errorf("cut value not interned")
+	}
+}
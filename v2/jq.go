@@ -0,0 +1,49 @@
+package flatten
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// JQStyle writes keys as jq paths, e.g. `.a.b[0].c` or `.a."weird key"`, ready to paste into a jq filter.
+// Use it with the prefix ".", for the leading dot jq wants: Flatten(nested, ".", JQStyle).
+var JQStyle = SeparatorStyle{Middle: ".", IndexBefore: "[", IndexAfter: "]", Escaper: jqQuoter{}}
+
+// jqQuoter leaves keys which jq accepts as identifiers bare, and writes others as JSON strings.
+type jqQuoter struct{}
+
+func (jqQuoter) Escape(segment string) string {
+	if isJQIdentifier(segment) {
+		return segment
+	}
+
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.Encode(segment) // strings always encode
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func (jqQuoter) Unescape(segment string) string {
+	var s string
+	if strings.HasPrefix(segment, `"`) && json.Unmarshal([]byte(segment), &s) == nil {
+		return s
+	}
+	return segment
+}
+
+func isJQIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, c := range []byte(s) {
+		switch {
+		case c == '_', 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z':
+		case '0' <= c && c <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,44 @@
+package flatten
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestJQStyle(t *testing.T) {
+	nested := map[string]interface{}{
+		"a": map[string]interface{}{
+			"b": []interface{}{
+				map[string]interface{}{"c": 1.0},
+			},
+			"weird key": "x",
+			"9lives":    true,
+		},
+		"<html>": "y",
+		`q"uote`: "z",
+	}
+
+	got, err := Flatten(nested, ".", JQStyle)
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+
+	want := map[string]interface{}{
+		`.a.b[0].c`:      1.0,
+		`.a."weird key"`: "x",
+		`.a."9lives"`:    true,
+		`."<html>"`:      "y",
+		`."q\"uote"`:     "z",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %v wanted: %v", got, want)
+	}
+}
+
+func TestJQQuoter(t *testing.T) {
+	for i, s := range []string{"plain", "with space", `"quoted"`, "", "é", "_x9"} {
+		if back := (jqQuoter{}).Unescape((jqQuoter{}).Escape(s)); back != s {
+			t.Errorf("%d: round trip mismatch, got: %q wanted: %q", i+1, back, s)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package flatten
+
+// stripJSONC blanks the comments of JSONC, // to the end of the line and /* to */, and drops commas
+// trailing the last member of an object or array, leaving plain JSON.  Strings are kept as they are.
+// Comments become spaces, so offsets in errors from decoding still point into the original.
+func stripJSONC(b []byte) []byte {
+	out := make([]byte, 0, len(b))
+	comma := -1 // Index in out of a comma which may yet be trailing
+
+	for i := 0; i < len(b); i++ {
+		c := b[i]
+		switch {
+		case c == '"':
+			comma = -1
+			j := i + 1
+			for ; j < len(b) && b[j] != '"'; j++ {
+				if b[j] == '\\' {
+					j++
+				}
+			}
+			if j >= len(b) {
+				j = len(b) - 1
+			}
+			out = append(out, b[i:j+1]...)
+			i = j
+		case c == '/' && i+1 < len(b) && b[i+1] == '/':
+			for ; i < len(b) && b[i] != '\n'; i++ {
+				out = append(out, ' ')
+			}
+			if i < len(b) {
+				out = append(out, '\n')
+			}
+		case c == '/' && i+1 < len(b) && b[i+1] == '*':
+			out = append(out, ' ', ' ')
+			for i += 2; i < len(b) && !(b[i] == '*' && i+1 < len(b) && b[i+1] == '/'); i++ {
+				if b[i] == '\n' {
+					out = append(out, '\n')
+				} else {
+					out = append(out, ' ')
+				}
+			}
+			if i < len(b) {
+				out = append(out, ' ', ' ')
+				i++
+			}
+		case c == ',':
+			comma = len(out)
+			out = append(out, c)
+		case c == '}' || c == ']':
+			if comma >= 0 {
+				out[comma] = ' '
+			}
+			comma = -1
+			out = append(out, c)
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			out = append(out, c)
+		default:
+			comma = -1
+			out = append(out, c)
+		}
+	}
+
+	return out
+}
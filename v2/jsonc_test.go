@@ -0,0 +1,27 @@
+package flatten
+
+import (
+	"testing"
+)
+
+func TestStripJSONC(t *testing.T) {
+	cases := []struct {
+		test string
+		want string
+	}{
+		// 1
+		{`{"a": 1, // one` + "\n" + `"b": [1, 2,],}`, `{"a": 1,       ` + "\n" + `"b": [1, 2 ] }`},
+		// 2
+		{`/* head */ {"a": "x // not /* a */ comment, ]"}`, `           {"a": "x // not /* a */ comment, ]"}`},
+		// 3
+		{`{"a": "q\"", /* x` + "\n" + `*/ }`, `{"a": "q\""      ` + "\n" + `   }`},
+		// 4
+		{`{"a": [1, /* c */ ], }`, `{"a": [1          ]  }`},
+	}
+
+	for i, test := range cases {
+		if got := string(stripJSONC([]byte(test.test))); got != test.want {
+			t.Errorf("%d: mismatch, got: %q wanted: %q", i+1, got, test.want)
+		}
+	}
+}
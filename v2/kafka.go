@@ -0,0 +1,32 @@
+package flatten
+
+// A Header is a record header, with the same fields as the header types of common Kafka clients, so
+// converting is direct, e.g. kafka.Header(h) with segmentio/kafka-go.
+type Header struct {
+	Key   string
+	Value []byte
+}
+
+// FlattenHeaders flattens nested into record headers, in key order, with values rendered by encode, or
+// by TextValue if encode is nil.
+func FlattenHeaders(nested map[string]interface{}, prefix string, style SeparatorStyle, encode ValueEncoder) ([]Header, error) {
+	flat, err := Flatten(nested, prefix, style)
+	if err != nil {
+		return nil, err
+	}
+
+	if encode == nil {
+		encode = TextValue
+	}
+
+	headers := make([]Header, 0, len(flat))
+	for _, k := range sortedKeys(flat) {
+		value, err := encode(flat[k])
+		if err != nil {
+			return nil, err
+		}
+		headers = append(headers, Header{Key: k, Value: value})
+	}
+
+	return headers, nil
+}
@@ -0,0 +1,51 @@
+package flatten
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFlattenHeaders(t *testing.T) {
+	nested := map[string]interface{}{
+		"trace": map[string]interface{}{"id": "abc", "sampled": true},
+		"retry": 2.0,
+		"none":  nil,
+	}
+
+	cases := []struct {
+		encode ValueEncoder
+		want   []Header
+	}{
+		// 1
+		{
+			nil,
+			[]Header{
+				{"meta.none", []byte("")},
+				{"meta.retry", []byte("2")},
+				{"meta.trace.id", []byte("abc")},
+				{"meta.trace.sampled", []byte("true")},
+			},
+		},
+		// 2
+		{
+			JSONValue,
+			[]Header{
+				{"meta.none", []byte("null")},
+				{"meta.retry", []byte("2")},
+				{"meta.trace.id", []byte(`"abc"`)},
+				{"meta.trace.sampled", []byte("true")},
+			},
+		},
+	}
+
+	for i, test := range cases {
+		got, err := FlattenHeaders(nested, "meta.", DotStyle, test.encode)
+		if err != nil {
+			t.Errorf("%d: failed to flatten: %v", i+1, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%d: mismatch, got: %q wanted: %q", i+1, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package flatten
+
+import (
+	"sort"
+	"strconv"
+)
+
+// NestInto merges a flat map into an existing nested one, e.g. to layer command-line overrides onto a
+// parsed config.  Keys are split by style, as Unflatten does; each value replaces the one at its key,
+// leaving the rest of dst as it is.  Indexes address existing slice elements, or extend the slice.
+//
+// A value meeting a map or slice, or a key descending through a value, is a conflict, and fails with a
+// *ConflictError.
+func NestInto(dst, flat map[string]interface{}, style SeparatorStyle) error {
+	return Options{}.NestInto(dst, flat, style)
+}
+
+// NestInto merges a flat map into an existing nested one, as the package-level NestInto does, settling
+// conflicts with the options' strategy: PreferNested keeps maps and slices, and PreferScalar keeps
+// values, whether found in dst or flat.
+func (o Options) NestInto(dst, flat map[string]interface{}, style SeparatorStyle) error {
+	done := observe("Options.NestInto", len(flat))
+
+	over, err := o.nest(flat, o.styled(style).SplitKey)
+	if err == nil {
+		_, err = o.merge(dst, over, nil, style)
+	}
+	done(len(flat), err)
+
+	return err
+}
+
+// merge merges over into node, found at path, returning the result.
+func (o Options) merge(node, over interface{}, path []string, style SeparatorStyle) (interface{}, error) {
+	fail := func() error {
+		key := ""
+		for i, seg := range path {
+			key = enkey(i == 0, key, seg, style)
+		}
+		return &ConflictError{Key: key, Path: append([]string(nil), path...)}
+	}
+	conflict := func(container, scalar interface{}) (interface{}, error) {
+		switch o.Conflicts {
+		case PreferNested:
+			return container, nil
+		case PreferScalar:
+			return scalar, nil
+		}
+		return nil, fail()
+	}
+
+	overMap, isMap := over.(map[string]interface{})
+	if list, ok := over.([]interface{}); ok {
+		overMap, isMap = make(map[string]interface{}, len(list)), true
+		for i, v := range list {
+			overMap[strconv.Itoa(i)] = v
+		}
+	}
+	if !isMap {
+		switch node.(type) {
+		case map[string]interface{}, []interface{}:
+			return conflict(node, over)
+		}
+		return over, nil
+	}
+
+	switch n := node.(type) {
+	case map[string]interface{}:
+		for k, v := range overMap {
+			merged, err := o.merge(n[k], v, append(path, k), style)
+			if err != nil {
+				return nil, err
+			}
+			n[k] = merged
+		}
+		return n, nil
+
+	case []interface{}:
+		keys := make([]int, 0, len(overMap))
+		for k := range overMap {
+			i, err := strconv.Atoi(k)
+			if err != nil || i < 0 || strconv.Itoa(i) != k || i >= len(n)+maxFillIndex {
+				return nil, fail() // a map, where a slice is
+			}
+			keys = append(keys, i)
+		}
+		sort.Ints(keys)
+		for _, i := range keys {
+			for i >= len(n) {
+				n = append(n, nil)
+			}
+			merged, err := o.merge(n[i], overMap[strconv.Itoa(i)], append(path, strconv.Itoa(i)), style)
+			if err != nil {
+				return nil, err
+			}
+			n[i] = merged
+		}
+		return n, nil
+
+	case nil:
+		return over, nil
+	}
+
+	return conflict(over, node)
+}
@@ -0,0 +1,97 @@
+package flatten
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestNestInto(t *testing.T) {
+	dst := map[string]interface{}{
+		"server": map[string]interface{}{
+			"host":  "a",
+			"port":  80.0,
+			"hosts": []interface{}{"x", map[string]interface{}{"name": "y", "up": true}},
+		},
+		"debug": false,
+	}
+	flat := map[string]interface{}{
+		"server.port":         8080.0,
+		"server.hosts.1.name": "z",
+		"server.hosts.2":      "w",
+		"server.tls.on":       true,
+		"debug":               true,
+	}
+
+	if err := NestInto(dst, flat, DotStyle); err != nil {
+		t.Fatalf("failed to nest: %v", err)
+	}
+	want := map[string]interface{}{
+		"server": map[string]interface{}{
+			"host":  "a",
+			"port":  8080.0,
+			"hosts": []interface{}{"x", map[string]interface{}{"name": "z", "up": true}, "w"},
+			"tls":   map[string]interface{}{"on": true},
+		},
+		"debug": true,
+	}
+	if !reflect.DeepEqual(dst, want) {
+		t.Errorf("mismatch, got: %v wanted: %v", dst, want)
+	}
+}
+
+func TestNestIntoConflicts(t *testing.T) {
+	cases := []struct {
+		strategy ConflictStrategy
+		flat     map[string]interface{}
+		want     map[string]interface{}
+		err      error
+	}{
+		// 1 -- a value over a map
+		{
+			ErrorOnConflict,
+			map[string]interface{}{"a": 1.0},
+			nil,
+			LeafParentConflictError,
+		},
+		// 2 -- a key through a value
+		{
+			ErrorOnConflict,
+			map[string]interface{}{"s.t": 1.0},
+			nil,
+			LeafParentConflictError,
+		},
+		// 3
+		{
+			PreferNested,
+			map[string]interface{}{"a": 1.0, "s.t": 1.0},
+			map[string]interface{}{"a": map[string]interface{}{"b": "c"}, "s": map[string]interface{}{"t": 1.0}},
+			nil,
+		},
+		// 4
+		{
+			PreferScalar,
+			map[string]interface{}{"a": 1.0, "s.t": 1.0},
+			map[string]interface{}{"a": 1.0, "s": "v"},
+			nil,
+		},
+	}
+
+	for i, test := range cases {
+		dst := map[string]interface{}{"a": map[string]interface{}{"b": "c"}, "s": "v"}
+		err := Options{Conflicts: test.strategy}.NestInto(dst, test.flat, DotStyle)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%d: error mismatch, got: [%v], wanted: [%v]", i+1, err, test.err)
+			continue
+		}
+		if err == nil && !reflect.DeepEqual(dst, test.want) {
+			t.Errorf("%d: mismatch, got: %v wanted: %v", i+1, dst, test.want)
+		}
+	}
+
+	var conflict *ConflictError
+	err := NestInto(map[string]interface{}{"s": "v"}, map[string]interface{}{"s.t.u": 1.0}, DotStyle)
+	if !errors.As(err, &conflict) || conflict.Key != "s" {
+		t.Errorf("error mismatch, got: [%v], wanted a *ConflictError at %q", err, "s")
+	}
+}
@@ -0,0 +1,313 @@
+package flatten
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// A path does not lead through maps and slice indexes
+var NotValidPathError = errors.New("Not a valid path: must lead through maps and slice indexes")
+
+// A Mirror holds a nested map along with its flattened form, keeping the flat view current as values are
+// set and deleted through it, by reflattening only what changed.  It is safe for concurrent use.
+//
+// The Mirror takes the nested map over; change it only through the Mirror.
+type Mirror struct {
+	mu          sync.RWMutex
+	nested      map[string]interface{}
+	flat        map[string]interface{}
+	shared      map[string]int // The number of paths making each flat key made by more than one
+	prefix      string
+	style       SeparatorStyle
+	subscribers []func(changed []string)
+}
+
+// NewMirror flattens nested, as Flatten does, and returns a Mirror of it.  Where distinct paths make the
+// same key, the last of them, in sorted order of map keys, gives its value, as under KeepLast.
+func NewMirror(nested map[string]interface{}, prefix string, style SeparatorStyle) (*Mirror, error) {
+	m := &Mirror{nested: nested, prefix: prefix, style: style}
+
+	var err error
+	if m.flat, m.shared, err = m.flattenAt(true, prefix, nested); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Get returns the value of a flattened key.
+func (m *Mirror) Get(key string) (interface{}, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	v, ok := m.flat[key]
+	return v, ok
+}
+
+// Flat returns a copy of the flat view.
+func (m *Mirror) Flat() map[string]interface{} {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	flat := make(map[string]interface{}, len(m.flat))
+	for k, v := range m.flat {
+		flat[k] = v
+	}
+	return flat
+}
+
+// Subscribe registers fn to be called after each change, with the flattened keys added, removed or given
+// new values, sorted.  Calls are made outside the Mirror's lock, so fn may read the Mirror.
+func (m *Mirror) Subscribe(fn func(changed []string)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.subscribers = append(m.subscribers, fn)
+}
+
+// Set sets the value at path, a list of map keys and slice indexes, adding maps for missing keys.
+func (m *Mirror) Set(path []string, v interface{}) error {
+	return m.change(path, true, func(parent interface{}, seg string) {
+		switch p := parent.(type) {
+		case map[string]interface{}:
+			p[seg] = v
+		case []interface{}:
+			n, _ := strconv.Atoi(seg)
+			p[n] = v
+		}
+	})
+}
+
+// Delete removes the value at path.  Slice elements are set to nil instead, keeping later indexes.
+func (m *Mirror) Delete(path []string) error {
+	return m.change(path, false, func(parent interface{}, seg string) {
+		switch p := parent.(type) {
+		case map[string]interface{}:
+			delete(p, seg)
+		case []interface{}:
+			n, _ := strconv.Atoi(seg)
+			p[n] = nil
+		}
+	})
+}
+
+// change applies edit to the parent of the node at path, adding missing parents if create, and
+// reflattens the values from the highest node changed.
+func (m *Mirror) change(path []string, create bool, edit func(parent interface{}, seg string)) error {
+	if len(path) == 0 {
+		return NotValidPathError
+	}
+
+	m.mu.Lock()
+
+	var (
+		parent interface{} = m.nested
+		key                = m.prefix
+		marked bool        // Whether the highest node changed is found
+		at     struct {
+			parent  interface{}
+			seg     string
+			key     string
+			old     interface{}
+			existed bool
+		}
+	)
+	// restore puts back the highest node changed, undoing the edit and any parents added below it.
+	restore := func() {
+		switch p := at.parent.(type) {
+		case map[string]interface{}:
+			if at.existed {
+				p[at.seg] = at.old
+			} else {
+				delete(p, at.seg)
+			}
+		case []interface{}:
+			n, _ := strconv.Atoi(at.seg)
+			p[n] = at.old
+		}
+	}
+	for i, seg := range path {
+		child, ok, err := childOf(parent, seg)
+		if err != nil {
+			if marked {
+				restore()
+			}
+			m.mu.Unlock()
+			return fmt.Errorf("%w: %q", err, path)
+		}
+		if _, isSlice := parent.([]interface{}); isSlice {
+			n, _ := strconv.Atoi(seg)
+			key = enindex(i == 0, key, n, m.style)
+		} else {
+			key = enkey(i == 0, key, seg, m.style)
+		}
+
+		last := i == len(path)-1
+		if !marked && (last || (create && child == nil)) {
+			marked = true
+			at.parent, at.seg, at.key, at.old, at.existed = parent, seg, key, child, ok
+		}
+		if last {
+			edit(parent, seg)
+			break
+		}
+
+		if child == nil && create {
+			child = make(map[string]interface{})
+			switch p := parent.(type) {
+			case map[string]interface{}:
+				p[seg] = child
+			case []interface{}:
+				n, _ := strconv.Atoi(seg)
+				p[n] = child
+			}
+		}
+		parent = child
+	}
+
+	var (
+		before, after   = map[string]interface{}{}, map[string]interface{}{}
+		beforeN, afterN map[string]int
+		err             error
+	)
+	if at.existed {
+		if before, beforeN, err = m.flattenAt(false, at.key, at.old); err != nil {
+			restore()
+			m.mu.Unlock()
+			return err
+		}
+	}
+	if now, ok, _ := childOf(at.parent, at.seg); ok {
+		if after, afterN, err = m.flattenAt(false, at.key, now); err != nil {
+			restore()
+			m.mu.Unlock()
+			return err
+		}
+	}
+
+	// Count the paths left making each key, and take the values of keys still made by paths out of the
+	// change, as they collide, from the whole.
+	counts := make(map[string]int, len(before)+len(after))
+	var stale bool
+	for _, flat := range []map[string]interface{}{before, after} {
+		for k := range flat {
+			if _, ok := counts[k]; ok {
+				continue
+			}
+			n := m.paths(k)
+			if _, ok := before[k]; ok {
+				n -= pathsIn(beforeN, k)
+			}
+			own := 0
+			if _, ok := after[k]; ok {
+				own = pathsIn(afterN, k)
+			}
+			counts[k] = n + own
+			stale = stale || n > 0
+		}
+	}
+	var whole map[string]interface{}
+	if stale {
+		if whole, _, err = m.flattenAt(true, m.prefix, m.nested); err != nil {
+			restore()
+			m.mu.Unlock()
+			return err
+		}
+	}
+
+	var keys []string
+	for k, n := range counts {
+		old, had := m.flat[k]
+		switch {
+		case n == 0:
+			delete(m.flat, k)
+		case stale && (m.paths(k) > pathsIn(beforeN, k) || n > pathsIn(afterN, k)):
+			m.flat[k] = whole[k]
+		default:
+			m.flat[k] = after[k]
+		}
+
+		if n > 1 {
+			m.shared[k] = n
+		} else {
+			delete(m.shared, k)
+		}
+
+		if v, has := m.flat[k]; had != has || !reflect.DeepEqual(old, v) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	subscribers := m.subscribers
+	m.mu.Unlock()
+
+	if len(keys) > 0 {
+		for _, fn := range subscribers {
+			fn(keys)
+		}
+	}
+	return nil
+}
+
+// childOf returns the child of parent at seg, a map key or slice index, and whether it exists.
+func childOf(parent interface{}, seg string) (interface{}, bool, error) {
+	switch p := parent.(type) {
+	case map[string]interface{}:
+		child, ok := p[seg]
+		return child, ok, nil
+	case []interface{}:
+		n, err := strconv.Atoi(seg)
+		if err != nil || n < 0 || n >= len(p) {
+			return nil, false, NotValidPathError
+		}
+		return p[n], true, nil
+	}
+	return nil, false, NotValidPathError
+}
+
+// paths returns the number of paths making the flat key.
+func (m *Mirror) paths(key string) int {
+	if n, ok := m.shared[key]; ok {
+		return n
+	}
+	if _, ok := m.flat[key]; ok {
+		return 1
+	}
+	return 0
+}
+
+// pathsIn returns the number of paths making key, in the counts of a flattenAt which made it.
+func pathsIn(counts map[string]int, key string) int {
+	if n, ok := counts[key]; ok {
+		return n
+	}
+	return 1
+}
+
+// flattenAt flattens the value v found at key, or, if top, the nested map v under the prefix key, also
+// returning the number of paths making each key made by more than one.
+func (m *Mirror) flattenAt(top bool, key string, v interface{}) (map[string]interface{}, map[string]int, error) {
+	flat := make(map[string]interface{})
+	shared := make(map[string]int)
+
+	v, err := Options{}.unwrap(v)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch v.(type) {
+	case map[string]interface{}, []interface{}, OrderedMap, Ranger, anyRanger:
+		f := flattener{style: m.style, opts: Options{Collisions: KeepLast}}
+		if err := f.flatten(top, flat, v, key, 1); err != nil {
+			return nil, nil, err
+		}
+		for k, paths := range f.collisions {
+			shared[k] = len(paths)
+		}
+	default:
+		flat[m.style.finish(key)] = v
+	}
+
+	return flat, shared, nil
+}
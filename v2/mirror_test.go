@@ -0,0 +1,205 @@
+package flatten
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestMirror(t *testing.T) {
+	m, err := NewMirror(map[string]interface{}{
+		"a": map[string]interface{}{"b": 1.0, "c": 2.0},
+		"l": []interface{}{"x", "y"},
+		"n": nil,
+	}, "", DotStyle)
+	if err != nil {
+		t.Fatalf("failed to mirror: %v", err)
+	}
+
+	var notified [][]string
+	m.Subscribe(func(changed []string) { notified = append(notified, changed) })
+
+	steps := []struct {
+		op      func() error
+		want    map[string]interface{}
+		changed []string
+	}{
+		// 1
+		{
+			func() error { return m.Set([]string{"a", "b"}, 10.0) },
+			map[string]interface{}{"a.b": 10.0, "a.c": 2.0, "l.0": "x", "l.1": "y", "n": nil},
+			[]string{"a.b"},
+		},
+		// 2 -- replace a map by a scalar
+		{
+			func() error { return m.Set([]string{"a"}, "flat") },
+			map[string]interface{}{"a": "flat", "l.0": "x", "l.1": "y", "n": nil},
+			[]string{"a", "a.b", "a.c"},
+		},
+		// 3 -- through a slice
+		{
+			func() error { return m.Set([]string{"l", "1"}, map[string]interface{}{"z": true}) },
+			map[string]interface{}{"a": "flat", "l.0": "x", "l.1.z": true, "n": nil},
+			[]string{"l.1", "l.1.z"},
+		},
+		// 4 -- adding parents, in place of a nil
+		{
+			func() error { return m.Set([]string{"n", "p", "q"}, "deep") },
+			map[string]interface{}{"a": "flat", "l.0": "x", "l.1.z": true, "n.p.q": "deep"},
+			[]string{"n", "n.p.q"},
+		},
+		// 5
+		{
+			func() error { return m.Delete([]string{"n"}) },
+			map[string]interface{}{"a": "flat", "l.0": "x", "l.1.z": true},
+			[]string{"n.p.q"},
+		},
+		// 6 -- nothing to delete
+		{
+			func() error { return m.Delete([]string{"missing"}) },
+			map[string]interface{}{"a": "flat", "l.0": "x", "l.1.z": true},
+			nil,
+		},
+		// 7 -- the same value again
+		{
+			func() error { return m.Set([]string{"a"}, "flat") },
+			map[string]interface{}{"a": "flat", "l.0": "x", "l.1.z": true},
+			nil,
+		},
+	}
+
+	for i, step := range steps {
+		notified = nil
+		if err := step.op(); err != nil {
+			t.Errorf("%d: failed: %v", i+1, err)
+			continue
+		}
+		if got := m.Flat(); !reflect.DeepEqual(got, step.want) {
+			t.Errorf("%d: mismatch, got: %v wanted: %v", i+1, got, step.want)
+		}
+		var changed []string
+		if len(notified) > 0 {
+			changed = notified[0]
+		}
+		if !reflect.DeepEqual(changed, step.changed) || len(notified) > 1 {
+			t.Errorf("%d: notification mismatch, got: %v wanted: %v", i+1, notified, step.changed)
+		}
+	}
+
+	if v, ok := m.Get("l.1.z"); !ok || v != true {
+		t.Errorf("get mismatch, got: %v wanted: %v", v, true)
+	}
+}
+
+func TestMirrorSetRanger(t *testing.T) {
+	m, err := NewMirror(map[string]interface{}{"a": 1.0}, "", DotStyle)
+	if err != nil {
+		t.Fatalf("failed to mirror: %v", err)
+	}
+
+	var sm sync.Map
+	sm.Store("k", "v")
+	if err := m.Set([]string{"r"}, &sm); err != nil {
+		t.Fatalf("failed to set: %v", err)
+	}
+	if got, want := m.Flat(), (map[string]interface{}{"a": 1.0, "r.k": "v"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %v wanted: %v", got, want)
+	}
+
+	if err := m.Delete([]string{"r"}); err != nil {
+		t.Fatalf("failed to delete: %v", err)
+	}
+	if got, want := m.Flat(), (map[string]interface{}{"a": 1.0}); !reflect.DeepEqual(got, want) {
+		t.Errorf("delete mismatch, got: %v wanted: %v", got, want)
+	}
+}
+
+func TestMirrorInvalidPaths(t *testing.T) {
+	m, err := NewMirror(map[string]interface{}{"a": "b", "l": []interface{}{1}}, "", DotStyle)
+	if err != nil {
+		t.Fatalf("failed to mirror: %v", err)
+	}
+
+	for i, path := range [][]string{{}, {"a", "b"}, {"l", "1"}, {"l", "x"}} {
+		if err := m.Set(path, 1); !errors.Is(err, NotValidPathError) {
+			t.Errorf("%d: error mismatch, got: [%v], wanted: [%v]", i+1, err, NotValidPathError)
+		}
+	}
+}
+
+func TestMirrorFailedChange(t *testing.T) {
+	m, err := NewMirror(map[string]interface{}{"a": 1.0}, "", DotStyle)
+	if err != nil {
+		t.Fatalf("failed to mirror: %v", err)
+	}
+
+	cyclic := map[string]interface{}{}
+	cyclic["self"] = cyclic
+
+	for i, path := range [][]string{{"b"}, {"x", "y"}, {"a"}} {
+		if err := m.Set(path, cyclic); !errors.Is(err, CyclicInputError) {
+			t.Errorf("%d: error mismatch, got: [%v], wanted: [%v]", i+1, err, CyclicInputError)
+		}
+		if want := map[string]interface{}{"a": 1.0}; !reflect.DeepEqual(m.nested, want) || !reflect.DeepEqual(m.Flat(), want) {
+			t.Errorf("%d: mismatch, got: %v and %v wanted: %v", i+1, m.nested, m.Flat(), want)
+		}
+	}
+}
+
+func TestMirrorCollisions(t *testing.T) {
+	// In sorted order of map keys, "a" comes before "a.b", so the latter gives the value.
+	m, err := NewMirror(map[string]interface{}{
+		"a":   map[string]interface{}{"b": 1.0},
+		"a.b": 2.0,
+	}, "", DotStyle)
+	if err != nil {
+		t.Fatalf("failed to mirror: %v", err)
+	}
+
+	var notified [][]string
+	m.Subscribe(func(changed []string) { notified = append(notified, changed) })
+
+	steps := []struct {
+		op      func() error
+		want    map[string]interface{}
+		changed []string
+	}{
+		// 1 -- the other path still makes the key
+		{
+			func() error { return m.Set([]string{"a", "b"}, 3.0) },
+			map[string]interface{}{"a.b": 2.0},
+			nil,
+		},
+		// 2
+		{
+			func() error { return m.Delete([]string{"a.b"}) },
+			map[string]interface{}{"a.b": 3.0},
+			[]string{"a.b"},
+		},
+		// 3 -- the last path gone
+		{
+			func() error { return m.Delete([]string{"a"}) },
+			map[string]interface{}{},
+			[]string{"a.b"},
+		},
+	}
+
+	for i, step := range steps {
+		notified = nil
+		if err := step.op(); err != nil {
+			t.Errorf("%d: failed: %v", i+1, err)
+			continue
+		}
+		if got := m.Flat(); !reflect.DeepEqual(got, step.want) {
+			t.Errorf("%d: mismatch, got: %v wanted: %v", i+1, got, step.want)
+		}
+		var changed []string
+		if len(notified) > 0 {
+			changed = notified[0]
+		}
+		if !reflect.DeepEqual(changed, step.changed) || len(notified) > 1 {
+			t.Errorf("%d: notification mismatch, got: %v wanted: %v", i+1, notified, step.changed)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package flatten
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"strings"
+)
+
+// WriteMultipart flattens nested with RailsStyle and writes each entry to w as a form field, in key order.
+// Values of type []byte or io.Reader are written as file parts, with the innermost key as the file name;
+// other values are written as text.  The caller remains responsible for closing w.
+func WriteMultipart(w *multipart.Writer, nested map[string]interface{}) error {
+	flat, err := Flatten(nested, "", RailsStyle)
+	if err != nil {
+		return err
+	}
+
+	for _, k := range sortedKeys(flat) {
+		switch v := flat[k].(type) {
+		case []byte:
+			err = writeMultipartFile(w, k, bytes.NewReader(v))
+		case io.Reader:
+			err = writeMultipartFile(w, k, v)
+		default:
+			err = w.WriteField(k, formatValue(v))
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeMultipartFile(w *multipart.Writer, key string, r io.Reader) error {
+	name := key
+	if i := strings.LastIndex(key, RailsStyle.Before); i >= 0 && strings.HasSuffix(key, RailsStyle.After) {
+		name = key[i+len(RailsStyle.Before) : len(key)-len(RailsStyle.After)]
+	}
+
+	part, err := w.CreateFormFile(key, RailsStyle.Escaper.Unescape(name))
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(part, r)
+	return err
+}
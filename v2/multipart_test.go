@@ -0,0 +1,69 @@
+package flatten
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWriteMultipart(t *testing.T) {
+	nested := map[string]interface{}{
+		"user": map[string]interface{}{
+			"name":   "jim",
+			"age":    42.0,
+			"tags":   []interface{}{"a", "b"},
+			"avatar": []byte("\x89PNG"),
+			"resume": strings.NewReader("plain text"),
+		},
+		"agree": true,
+	}
+
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	if err := WriteMultipart(w, nested); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+	w.Close()
+
+	form, err := multipart.NewReader(&body, w.Boundary()).ReadForm(1 << 20)
+	if err != nil {
+		t.Fatalf("failed to read form: %v", err)
+	}
+
+	wantValues := map[string][]string{
+		"agree":         {"true"},
+		"user[age]":     {"42"},
+		"user[name]":    {"jim"},
+		"user[tags][0]": {"a"},
+		"user[tags][1]": {"b"},
+	}
+	if !reflect.DeepEqual(form.Value, wantValues) {
+		t.Errorf("values mismatch, got: %v wanted: %v", form.Value, wantValues)
+	}
+
+	wantFiles := map[string]struct{ name, content string }{
+		"user[avatar]": {"avatar", "\x89PNG"},
+		"user[resume]": {"resume", "plain text"},
+	}
+	if len(form.File) != len(wantFiles) {
+		t.Errorf("files mismatch, got: %v wanted: %v", form.File, wantFiles)
+	}
+	for field, want := range wantFiles {
+		headers := form.File[field]
+		if len(headers) != 1 {
+			t.Errorf("%s: missing file part", field)
+			continue
+		}
+		if headers[0].Filename != want.name {
+			t.Errorf("%s: file name mismatch, got: %v wanted: %v", field, headers[0].Filename, want.name)
+		}
+		f, _ := headers[0].Open()
+		content, _ := io.ReadAll(f)
+		if string(content) != want.content {
+			t.Errorf("%s: content mismatch, got: %q wanted: %q", field, content, want.content)
+		}
+	}
+}
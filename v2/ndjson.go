@@ -0,0 +1,83 @@
+package flatten
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// A LineError reports a line of input which could not be flattened.
+type LineError struct {
+	Line int // From 1
+	Err  error
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+// FlattenNDJSON reads newline-delimited JSON objects from r and writes each, flattened, as one JSON
+// object per line to w.  Blank lines are skipped.  A line which is not a JSON object, or fails to
+// flatten, is left out and the rest carry on; the error returned then joins a *LineError for each, to
+// errors.As.  An error reading or writing stops at once.
+func FlattenNDJSON(r io.Reader, w io.Writer, prefix string, style SeparatorStyle) (err error) {
+	done := observe("FlattenNDJSON", 0)
+	keys := 0
+	defer func() { done(keys, err) }()
+
+	var lineErrs []error
+	br := bufio.NewReader(r)
+	bw := bufio.NewWriter(w)
+
+	for n := 1; ; n++ {
+		line, readErr := br.ReadString('\n')
+		if readErr != nil && readErr != io.EOF {
+			return readErr
+		}
+
+		if strings.TrimSpace(line) != "" {
+			flatb, err := flattenLine(line, prefix, style, &keys)
+			if err != nil {
+				lineErrs = append(lineErrs, &LineError{Line: n, Err: err})
+			} else if _, err := bw.Write(append(flatb, '\n')); err != nil {
+				return err
+			}
+		}
+
+		if readErr == io.EOF {
+			break
+		}
+	}
+
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+
+	return errors.Join(lineErrs...)
+}
+
+func flattenLine(line, prefix string, style SeparatorStyle, keys *int) ([]byte, error) {
+	if !isJsonMap.MatchString(line) {
+		return nil, NotValidJsonInputError
+	}
+
+	var nested map[string]interface{}
+	if err := json.Unmarshal([]byte(line), &nested); err != nil {
+		return nil, err
+	}
+
+	flatmap := make(map[string]interface{})
+	if err := flatten(true, flatmap, nested, prefix, style); err != nil {
+		return nil, err
+	}
+	*keys += len(flatmap)
+
+	return json.Marshal(flatmap)
+}
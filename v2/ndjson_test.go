@@ -0,0 +1,59 @@
+package flatten
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFlattenNDJSON(t *testing.T) {
+	cases := []struct {
+		in    string
+		want  string
+		lines []int // Of errors
+	}{
+		// 1
+		{
+			"{\"a\":{\"b\":1}}\n\n{\"c\":[true,null]}\r\n{\"d\":{}}",
+			"{\"a.b\":1}\n{\"c.0\":true,\"c.1\":null}\n{}\n",
+			nil,
+		},
+		// 2 -- bad lines are left out
+		{
+			"{\"a\":1}\n[1,2]\n{\"b\":\n{\"c\":3}\n",
+			"{\"a\":1}\n{\"c\":3}\n",
+			[]int{2, 3},
+		},
+		// 3
+		{"", "", nil},
+	}
+
+	for i, test := range cases {
+		var b strings.Builder
+		err := FlattenNDJSON(strings.NewReader(test.in), &b, "", DotStyle)
+
+		var lines []int
+		if err != nil {
+			for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+				var le *LineError
+				if !errors.As(e, &le) {
+					t.Errorf("%d: not a LineError: %v", i+1, e)
+					continue
+				}
+				lines = append(lines, le.Line)
+			}
+		}
+		if !reflect.DeepEqual(lines, test.lines) {
+			t.Errorf("%d: error lines mismatch, got: %v wanted: %v (%v)", i+1, lines, test.lines, err)
+		}
+		if got := b.String(); got != test.want {
+			t.Errorf("%d: mismatch, got: %q wanted: %q", i+1, got, test.want)
+		}
+	}
+
+	err := FlattenNDJSON(strings.NewReader("[1]\n"), &strings.Builder{}, "", DotStyle)
+	if !errors.Is(err, NotValidJsonInputError) {
+		t.Errorf("error mismatch, got: [%v], wanted: [%v]", err, NotValidJsonInputError)
+	}
+}
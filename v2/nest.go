@@ -0,0 +1,282 @@
+package flatten
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// A key is both a leaf and the parent of other keys
+var LeafParentConflictError = errors.New("Not a valid input: key is both a value and a parent")
+
+// A key does not follow the separators of its style
+var NotValidKeyError = errors.New("Not a valid input: key does not match the style")
+
+// nest builds a nested map from a flat one, with each key split into its segments by split.  A map
+// whose keys are exactly the indexes 0 through n-1 becomes a slice, save the top.
+func nest(flat map[string]interface{}, split func(key string) ([]string, error)) (map[string]interface{}, error) {
+	return Options{}.nest(flat, split)
+}
+
+// nest is the package-level nest, under the options.
+func (o Options) nest(flat map[string]interface{}, split func(key string) ([]string, error)) (map[string]interface{}, error) {
+	root := make(map[string]interface{})
+
+	for key, v := range flat {
+		path, err := split(key)
+		if err != nil {
+			return nil, err
+		}
+		if err := o.Conflicts.nestValue(root, path, o.coerce(key, v)); err != nil {
+			err.Key = key
+			return nil, err
+		}
+	}
+
+	for k, v := range root {
+		root[k] = o.toSlices(v)
+	}
+
+	return root, nil
+}
+
+// A ConflictStrategy says what unflattening does with a key which is both a value and a parent, as "a"
+// is with "a" and "a.b".
+type ConflictStrategy int
+
+const (
+	ErrorOnConflict ConflictStrategy = iota // Fail with a *ConflictError
+	PreferNested                            // Keep the parent, dropping the value
+	PreferScalar                            // Keep the value, dropping the children
+)
+
+// A ConflictError reports a key which is both a value and a parent.  It is a LeafParentConflictError, to
+// errors.Is.
+type ConflictError struct {
+	Key  string   // The flat key whose value could not be placed, or, for NestInto, the key of the conflict
+	Path []string // Its segments down to the conflict
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%v: %q, at %q", LeafParentConflictError, e.Key, e.Path)
+}
+
+func (e *ConflictError) Unwrap() error {
+	return LeafParentConflictError
+}
+
+// nestValue sets the value at path under node, adding maps as needed, and settling conflicts per the
+// strategy.
+func (s ConflictStrategy) nestValue(node map[string]interface{}, path []string, v interface{}) *ConflictError {
+	for i, seg := range path {
+		if i == len(path)-1 {
+			if _, ok := node[seg].(map[string]interface{}); ok {
+				if isEmptyMap(v) {
+					break // an empty parent adds nothing to one already there
+				}
+				switch s {
+				case PreferNested:
+					return nil
+				case ErrorOnConflict:
+					return &ConflictError{Path: path}
+				}
+			}
+			node[seg] = v
+			break
+		}
+
+		child, exists := node[seg]
+		if m, ok := child.(map[string]interface{}); ok {
+			node = m
+			continue
+		}
+		if exists {
+			switch s {
+			case PreferScalar:
+				return nil
+			case ErrorOnConflict:
+				return &ConflictError{Path: path[:i+1]}
+			}
+		}
+
+		m := make(map[string]interface{})
+		node[seg] = m
+		node = m
+	}
+
+	return nil
+}
+
+func isEmptyMap(v interface{}) bool {
+	m, ok := v.(map[string]interface{})
+	return ok && len(m) == 0
+}
+
+// toSlices converts, depth first, each map keyed by exactly the indexes from IndexBase on, without gaps
+// and as IndexWidth pads them, into a slice, and other maps keyed by indexes alone as the SparseArrays policy says.
+func (o Options) toSlices(v interface{}) interface{} {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return v
+	}
+
+	for k, child := range m {
+		m[k] = o.toSlices(child)
+	}
+
+	if len(m) == 0 {
+		return m
+	}
+	indexes := make([]int, 0, len(m))
+	keys := make(map[int]string, len(m))
+	for k := range m {
+		i, err := strconv.Atoi(k)
+		if err != nil || i < o.IndexBase || o.index(i-o.IndexBase) != k {
+			return m
+		}
+		indexes = append(indexes, i-o.IndexBase)
+		keys[i-o.IndexBase] = k
+	}
+	sort.Ints(indexes)
+
+	p := o.SparseArrays
+	n := len(m)
+	if last := indexes[len(indexes)-1]; last >= n {
+		switch {
+		case p == SparseFillNil && last < maxFillIndex:
+			n = last + 1
+		case p == SparseCompact:
+		default:
+			return m
+		}
+	}
+
+	list := make([]interface{}, n)
+	for j, i := range indexes {
+		if p == SparseCompact {
+			list[j] = m[keys[i]]
+		} else {
+			list[i] = m[keys[i]]
+		}
+	}
+
+	return list
+}
+
+// A SparseArrayPolicy says what Unflatten makes of maps keyed by indexes with gaps, such as "a.0" and
+// "a.3" alone.
+type SparseArrayPolicy int
+
+const (
+	SparseAsMap   SparseArrayPolicy = iota // Keep a map, keyed by the indexes as strings
+	SparseFillNil                          // Make a slice, with nils at the missing indexes
+	SparseCompact                          // Make a slice of the values present, in order of index
+)
+
+// The highest index SparseFillNil fills up to, against huge slices from stray keys; beyond it, maps stay
+const maxFillIndex = 1 << 16
+
+// A KeySplitter splits a flattened key into its segments, map keys and slice indexes, for unflattening.
+type KeySplitter interface {
+	SplitKey(key string) ([]string, error)
+}
+
+// SplitKey splits a flattened key into its segments with the style's Splitter, if set.  Otherwise, it
+// reverses the joins of the style, Before, Middle and After as well as IndexBefore and IndexAfter, and
+// unescapes map key segments if the style has an Escaper.  Sanitizers cannot be reversed; their output
+// is kept.
+func (style SeparatorStyle) SplitKey(key string) ([]string, error) {
+	if style.Splitter != nil {
+		return style.Splitter.SplitKey(key)
+	}
+	return splitKey(key, style)
+}
+
+func splitKey(key string, style SeparatorStyle) ([]string, error) {
+	opener := style.Before + style.Middle
+	indexOpener := style.IndexBefore
+	if style.IndexBefore == "" && style.IndexAfter == "" {
+		indexOpener = ""
+	}
+	if opener == "" && indexOpener == "" {
+		return []string{key}, nil
+	}
+
+	mark := escapeMark(style.Escaper)
+	find := func(s, sep string) int {
+		if sep == "" {
+			return -1
+		}
+		if mark == "" {
+			return strings.Index(s, sep)
+		}
+		for i := 0; i < len(s); {
+			if strings.HasPrefix(s[i:], mark) {
+				i += len(mark)
+				i += escapedLen(s[i:], mark)
+				continue
+			}
+			if strings.HasPrefix(s[i:], sep) {
+				return i
+			}
+			i++
+		}
+		return -1
+	}
+	next := func(s string) int {
+		i, j := find(s, opener), find(s, indexOpener)
+		if i < 0 || (j >= 0 && j < i) {
+			return j
+		}
+		return i
+	}
+	unescape := func(seg string) string {
+		if style.Escaper != nil {
+			return style.Escaper.Unescape(seg)
+		}
+		return seg
+	}
+
+	end := next(key)
+	if end < 0 {
+		return []string{unescape(key)}, nil
+	}
+	segments := []string{unescape(key[:end])}
+
+	for rest := key[end:]; rest != ""; {
+		index := indexOpener != "" && strings.HasPrefix(rest, indexOpener) &&
+			!(opener != "" && len(opener) > len(indexOpener) && strings.HasPrefix(rest, opener))
+
+		closer := style.After
+		if index {
+			rest = rest[len(indexOpener):]
+			closer = style.IndexAfter
+		} else if opener != "" && strings.HasPrefix(rest, opener) {
+			rest = rest[len(opener):]
+		} else {
+			return nil, fmt.Errorf("%w: %q", NotValidKeyError, key)
+		}
+
+		var seg string
+		if closer != "" {
+			i := find(rest, closer)
+			if i < 0 {
+				return nil, fmt.Errorf("%w: %q", NotValidKeyError, key)
+			}
+			seg, rest = rest[:i], rest[i+len(closer):]
+		} else if i := next(rest); i >= 0 {
+			seg, rest = rest[:i], rest[i:]
+		} else {
+			seg, rest = rest, ""
+		}
+
+		if !index {
+			seg = unescape(seg)
+		}
+		segments = append(segments, seg)
+	}
+
+	return segments, nil
+}
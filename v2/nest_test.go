@@ -0,0 +1,137 @@
+package flatten
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNest(t *testing.T) {
+	split := func(key string) ([]string, error) { return strings.Split(key, "."), nil }
+
+	cases := []struct {
+		flat map[string]interface{}
+		want map[string]interface{}
+		err  error
+	}{
+		// 1
+		{
+			map[string]interface{}{"a.b": 1, "a.c": 2, "d": 3},
+			map[string]interface{}{"a": map[string]interface{}{"b": 1, "c": 2}, "d": 3},
+			nil,
+		},
+		// 2 -- slices from indexes
+		{
+			map[string]interface{}{"a.0": "x", "a.1.b": "y", "c.1": "z", "d.01": "w"},
+			map[string]interface{}{
+				"a": []interface{}{"x", map[string]interface{}{"b": "y"}},
+				"c": map[string]interface{}{"1": "z"},
+				"d": map[string]interface{}{"01": "w"},
+			},
+			nil,
+		},
+		// 3 -- top level stays a map
+		{
+			map[string]interface{}{"0": "x"},
+			map[string]interface{}{"0": "x"},
+			nil,
+		},
+		// 4 -- an empty map merges with a sibling's parent
+		{
+			map[string]interface{}{"a": map[string]interface{}{}, "a.b": 1, "c": map[string]interface{}{}},
+			map[string]interface{}{"a": map[string]interface{}{"b": 1}, "c": map[string]interface{}{}},
+			nil,
+		},
+		// 5
+		{
+			map[string]interface{}{"a": 1, "a.b": 2},
+			nil,
+			LeafParentConflictError,
+		},
+		// 6 -- a nil leaf still conflicts
+		{
+			map[string]interface{}{"a": nil, "a.b": 2},
+			nil,
+			LeafParentConflictError,
+		},
+	}
+
+	for i, test := range cases {
+		got, err := nest(test.flat, split)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%d: error mismatch, got: [%v], wanted: [%v]", i+1, err, test.err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%d: mismatch, got: %v wanted: %v", i+1, got, test.want)
+		}
+	}
+}
+
+func TestSplitKey(t *testing.T) {
+	cases := []struct {
+		key   string
+		style SeparatorStyle
+		want  []string
+		err   error
+	}{
+		// 1
+		{"a.b.0.c", DotStyle, []string{"a", "b", "0", "c"}, nil},
+		// 2
+		{"a[b][0][c]", RailsStyle, []string{"a", "b", "0", "c"}, nil},
+		// 3 -- escaped brackets
+		{"a%5B0%5D[b%5D]", RailsStyle, []string{"a[0]", "b]"}, nil},
+		// 4 -- bracketed indexes
+		{"my-app.hosts[1].port", SpringStyle, []string{"my-app", "hosts", "1", "port"}, nil},
+		// 5 -- escaped separators
+		{`k8s\.io.name[0]`, HelmStyle, []string{"k8s.io", "name", "0"}, nil},
+		// 6
+		{"plain", DotStyle, []string{"plain"}, nil},
+		// 7
+		{"a(b", SeparatorStyle{Before: "(", After: ")"}, nil, NotValidKeyError},
+		// 8
+		{"a[b]c", RailsStyle, nil, NotValidKeyError},
+		// 9
+		{"a%2Fb/c", URIPathStyle, []string{"a/b", "c"}, nil},
+		// 10 -- custom groupings
+		{"a(b)(0)(c)", SeparatorStyle{Before: "(", After: ")"}, []string{"a", "b", "0", "c"}, nil},
+		// 11
+		{"a--b--c", SeparatorStyle{Middle: "--"}, []string{"a", "b", "c"}, nil},
+		// 12
+		{`HKLM\C:%5CTemp\0`, BackslashStyle, []string{"HKLM", `C:\Temp`, "0"}, nil},
+	}
+
+	for i, test := range cases {
+		got, err := test.style.SplitKey(test.key)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%d: error mismatch, got: [%v], wanted: [%v]", i+1, err, test.err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%d: mismatch, got: %q wanted: %q", i+1, got, test.want)
+		}
+	}
+}
+
+type colonSplitter struct{}
+
+func (colonSplitter) SplitKey(key string) ([]string, error) {
+	return strings.Split(key, ":"), nil
+}
+
+func TestSplitter(t *testing.T) {
+	style := SeparatorStyle{Middle: ".", Splitter: colonSplitter{}}
+
+	got, err := Unflatten(map[string]interface{}{"a:b": 1, "a:c": 2}, style)
+	if err != nil {
+		t.Fatalf("failed to unflatten: %v", err)
+	}
+	want := map[string]interface{}{"a": map[string]interface{}{"b": 1, "c": 2}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %v wanted: %v", got, want)
+	}
+}
@@ -0,0 +1,51 @@
+package flatten
+
+import (
+	"net/url"
+	"strings"
+)
+
+// DeepObjectQuery serializes value as an OpenAPI query parameter of style deepObject, with explode true,
+// e.g. "filter[status]=active&filter[tags][0]=x".  Nested objects add a bracketed key per level and arrays
+// a bracketed index per element.  Pairs are sorted by key.  The name, keys and values are percent-encoded,
+// all but the brackets delimiting keys.
+func DeepObjectQuery(name string, value map[string]interface{}) (string, error) {
+	style := SeparatorStyle{Before: "[", After: "]", Escaper: queryEscaper{}}
+
+	flat, err := Flatten(map[string]interface{}{name: value}, "", style)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for i, k := range sortedKeys(flat) {
+		if i > 0 {
+			b.WriteByte('&')
+		}
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(queryEscape(formatValue(flat[k])))
+	}
+
+	return b.String(), nil
+}
+
+// queryEscaper percent-encodes segments for use in a query string.
+type queryEscaper struct{}
+
+func (queryEscaper) Escape(segment string) string {
+	return queryEscape(segment)
+}
+
+func (queryEscaper) Unescape(segment string) string {
+	s, err := url.PathUnescape(segment)
+	if err != nil {
+		return segment
+	}
+	return s
+}
+
+// queryEscape is url.QueryEscape with spaces as "%20" rather than "+", as RFC 3986 has them.
+func queryEscape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
@@ -0,0 +1,53 @@
+package flatten
+
+import (
+	"testing"
+)
+
+func TestDeepObjectQuery(t *testing.T) {
+	cases := []struct {
+		name  string
+		value map[string]interface{}
+		want  string
+	}{
+		// 1 -- the OpenAPI specification's own example
+		{
+			"color",
+			map[string]interface{}{"R": 100.0, "G": 200.0, "B": 150.0},
+			"color[B]=150&color[G]=200&color[R]=100",
+		},
+		// 2 -- arrays and nested objects
+		{
+			"filter",
+			map[string]interface{}{
+				"status": "active",
+				"tags":   []interface{}{"x", "y"},
+				"owner":  map[string]interface{}{"id": 7.0},
+			},
+			"filter[owner][id]=7&filter[status]=active&filter[tags][0]=x&filter[tags][1]=y",
+		},
+		// 3 -- reserved characters in names, keys and values
+		{
+			"q s",
+			map[string]interface{}{"a&b": "c=d e", "[x]": nil},
+			"q%20s[%5Bx%5D]=&q%20s[a%26b]=c%3Dd%20e",
+		},
+		// 4
+		{
+			"empty",
+			map[string]interface{}{},
+			"",
+		},
+	}
+
+	for i, test := range cases {
+		got, err := DeepObjectQuery(test.name, test.value)
+		if err != nil {
+			t.Errorf("%d: failed to serialize: %v", i+1, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%d: mismatch, got: %v wanted: %v", i+1, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,472 @@
+package flatten
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Options adjust flattening, and unflattening, beyond the presentation of keys.  The zero value flattens
+// as Flatten does, and unflattens as Unflatten does.
+type Options struct {
+	InvalidUTF8 UTF8Policy    // What to do with map keys and string values which are not valid UTF-8
+	Bytes       BytesEncoding // How to present []byte values
+	Times       TimeFormat    // How to present time.Time values
+
+	MaxValueLen   int    // Cut longer string values to this many bytes (0 for no limit)
+	Ellipsis      string // Ends each cut value, "..." if empty
+	MarkTruncated bool   // Add a true "<key>__truncated" entry, styled as a child key, beside each cut value
+
+	Interner   *Interner // Where to intern string values, so repeats share memory (optional)
+	InternKeys bool      // Intern flattened keys as well, e.g. when flattening many documents of one shape
+
+	EscapeSeparators bool         // Percent-encode separator characters within keys, for styles without an Escaper
+	SeparatorEscape  string       // Under EscapeSeparators, precede those characters with this instead, e.g. `\`, unless it holds one of them
+	KeyTransform     KeyTransform // Recase each map key segment, before the style's Sanitizer, e.g. UpperSnakeKeys
+	StrictSanitize   bool         // Fail with a *CollisionError where sanitizing makes keys of distinct paths alike
+
+	Collisions CollisionPolicy // What to do when distinct paths make the same key
+
+	JSONC     bool // When reading JSON text, accept comments and trailing commas, as hand-edited files have
+	UseNumber bool // When reading JSON text, keep numbers as json.Number, so large integers like IDs keep every digit
+
+	TagName string    // Struct tag naming fields, e.g. "yaml" or "mapstructure", for FlattenValue and UnflattenInto ("json" if empty)
+	Nils    NilPolicy // Under FlattenValue, what to make of nil pointers and interfaces
+
+	Cycles CyclePolicy // What to do on meeting a map, slice or pointer within itself
+
+	OmitNulls  bool // Leave out keys whose value is null, for stores which reject them
+	KeepEmpty  bool // Keep empty maps and slices, as {} and [] values, rather than leaving out their keys
+	KeepArrays bool // Keep slices and arrays whole, as values, flattening only maps, as Elasticsearch would have them
+
+	Intermediates    IntermediatePolicy // Whether to give the maps and slices within keys too, e.g. "a" and "a.b" as well as "a.b.c"
+	IntermediateMark interface{}        // Under MarkIntermediates, the value of their keys (true if nil)
+
+	IndexBase  int // The first index of slices in keys, e.g. 1 for targets counting from 1; unflattening reads them likewise
+	IndexWidth int // Zero-pad indexes in keys to this many digits, e.g. "a.007", so keys sort in index order (0 for none)
+
+	MaxDepth      int         // Levels of keys to make, against deeply nested documents (0 for no limit)
+	DepthOverflow DepthPolicy // What to do with maps and slices beyond MaxDepth
+	MaxKeys       int         // Fail with a *KeyLimitError rather than make more keys than this, against hostile input (0 for no limit)
+
+	MaxKeyLen   int          // The longest key to make, in bytes, for column-name and label-length limits downstream (0 for no limit)
+	KeyOverflow KeyLenPolicy // What to do with keys longer than MaxKeyLen
+
+	KeyFormatter func(key interface{}) string // Renders the keys of map[interface{}]interface{}, as YAML decoders give, which are not strings (optional)
+
+	SparseArrays SparseArrayPolicy // When unflattening, what to make of indexes with gaps
+	Conflicts    ConflictStrategy  // When unflattening, what to do with keys which are both values and parents
+
+	CoerceValues bool                  // When unflattening, parse strings holding booleans and numbers, e.g. from env vars
+	KeepString   func(key string) bool // Under CoerceValues, whether to leave the string at a flat key as it is (optional)
+}
+
+// Unflatten builds a nested map from a flat one, as the package-level Unflatten does, under the options.
+func (o Options) Unflatten(flat map[string]interface{}, style SeparatorStyle) (map[string]interface{}, error) {
+	done := observe("Options.Unflatten", len(flat))
+	style = o.styled(style)
+
+	nested, err := o.nest(flat, style.SplitKey)
+	done(len(flat), err)
+	if err != nil {
+		return nil, err
+	}
+
+	return nested, nil
+}
+
+// styled returns style as the options adjust it.  With EscapeSeparators, a style lacking an Escaper
+// gets one for its separator characters (and '%', or the SeparatorEscape), so that keys holding them,
+// like "a.b" in DotStyle, unflatten as they were.  Maps keyed by indexes alone still unflatten as slices, and empty maps and
+// slices still vanish.  A SeparatorEscape holding a separator character, e.g. "." in DotStyle, would be
+// taken for a separator on unflattening, and is ignored for percent-encoding.  A KeyTransform comes before
+// the style's own Sanitizer.
+func (o Options) styled(style SeparatorStyle) SeparatorStyle {
+	if o.KeyTransform != KeepKeys {
+		if style.Sanitizer == nil {
+			style.Sanitizer = o.KeyTransform
+		} else {
+			style.Sanitizer = ChainSanitizers(o.KeyTransform, style.Sanitizer)
+		}
+	}
+	if o.EscapeSeparators && style.Escaper == nil {
+		var chars []byte
+		for _, sep := range []string{style.Before, style.Middle, style.After, style.IndexBefore, style.IndexAfter} {
+			for i := 0; i < len(sep); i++ {
+				if strings.IndexByte(string(chars), sep[i]) < 0 {
+					chars = append(chars, sep[i])
+				}
+			}
+		}
+		if o.SeparatorEscape != "" && !strings.ContainsAny(o.SeparatorEscape, string(chars)) {
+			style.Escaper = MarkEscaper{Mark: o.SeparatorEscape, Chars: string(chars)}
+		} else {
+			style.Escaper = PercentEscaper(chars)
+		}
+	}
+	return style
+}
+
+// coerce parses, under CoerceValues, the string v at key as a bool, int or float64, if it plainly holds
+// one: "true" and "false", integers without leading zeros (which codes like "0123" often have) and
+// finite decimal numbers.  Anything else is left as it is.
+func (o Options) coerce(key string, v interface{}) interface{} {
+	s, ok := v.(string)
+	if !o.CoerceValues || !ok || s == "" || (o.KeepString != nil && o.KeepString(key)) {
+		return v
+	}
+
+	switch s {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+
+	digits := strings.TrimPrefix(s, "-")
+	if digits == "" || digits[0] < '0' || digits[0] > '9' || (len(digits) > 1 && digits[0] == '0' && digits[1] != '.') {
+		return v
+	}
+	if n, err := strconv.ParseInt(s, 10, 0); err == nil {
+		return int(n)
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil && !math.IsInf(f, 0) {
+		return f
+	}
+
+	return v
+}
+
+// The default Options.Ellipsis
+const ellipsis = "..."
+
+// Flatten generates a flat map from a nested one, as the package-level Flatten does, under the options.
+func (o Options) Flatten(nested map[string]interface{}, prefix string, style SeparatorStyle) (map[string]interface{}, error) {
+	return settings{prefix: prefix, style: style, opts: o}.flatten("Options.Flatten", nested)
+}
+
+// FlattenPartial generates a flat map from a nested one, as the package-level FlattenPartial does, under
+// the options: upon an error, e.g. a *DepthError or *KeyLimitError, it also returns the keys flattened
+// until then.
+func (o Options) FlattenPartial(nested map[string]interface{}, prefix string, style SeparatorStyle) (map[string]interface{}, error) {
+	return settings{prefix: prefix, style: style, opts: o}.flattenPartial("Options.FlattenPartial", nested)
+}
+
+// FlattenInto flattens a nested map into dst, an existing flat one, as the package-level FlattenInto
+// does, under the options.  Keys already in dst collide with new ones as the Collisions policy says,
+// as if made first: KeepFirst keeps them, and CollectCollisions gathers their values with the new.
+func (o Options) FlattenInto(dst, nested map[string]interface{}, prefix string, style SeparatorStyle) error {
+	return settings{prefix: prefix, style: style, opts: o}.flattenInto("Options.FlattenInto", dst, nested)
+}
+
+// FlattenString generates a flat JSON map from a nested one, as the package-level FlattenString does,
+// under the options.
+func (o Options) FlattenString(nestedstr, prefix string, style SeparatorStyle) (string, error) {
+	flatb, err := o.flattenJSON("Options.FlattenString", []byte(nestedstr), prefix, style, isJsonMap)
+	if err != nil {
+		return "", err
+	}
+
+	return string(flatb), nil
+}
+
+// formatKey renders a map key which is not a string: by the KeyFormatter, if set, or else as fmt.Print
+// would, save nil as "null".
+func (o Options) formatKey(k interface{}) string {
+	switch {
+	case o.KeyFormatter != nil:
+		return o.KeyFormatter(k)
+	case k == nil:
+		return "null"
+	}
+	return fmt.Sprint(k)
+}
+
+// decodeJSON decodes JSON text, keeping numbers as json.Number under UseNumber.
+func (o Options) decodeJSON(b []byte) (interface{}, error) {
+	var v interface{}
+	if !o.UseNumber {
+		err := json.Unmarshal(b, &v)
+		return v, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("invalid data after top-level value, at offset %d", dec.InputOffset())
+	}
+	return v, nil
+}
+
+// index formats the slice index i for a key, from IndexBase and padded to IndexWidth.
+func (o Options) index(i int) string {
+	s := strconv.Itoa(i + o.IndexBase)
+	if len(s) < o.IndexWidth {
+		s = strings.Repeat("0", o.IndexWidth-len(s)) + s
+	}
+	return s
+}
+
+// tagName returns the struct tag naming fields.
+func (o Options) tagName() string {
+	if o.TagName == "" {
+		return "json"
+	}
+	return o.TagName
+}
+
+// value applies the options to a leaf value, save MaxValueLen and the Interner, which come after.
+func (o Options) value(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case string:
+		return o.text(v)
+	case []byte:
+		return o.Bytes.encode(v), nil
+	}
+	return v, nil
+}
+
+// truncate applies MaxValueLen to the leaf v at key, reporting whether it was cut.
+func (f *flattener) truncate(key string, v interface{}) (interface{}, bool) {
+	s, ok := v.(string)
+	if !ok || f.opts.MaxValueLen <= 0 || len(s) <= f.opts.MaxValueLen {
+		return v, false
+	}
+
+	e := f.opts.Ellipsis
+	if e == "" {
+		e = ellipsis
+	}
+	v = ellipsize(s, f.opts.MaxValueLen, e)
+
+	if f.trace != nil {
+		f.trace.add(f.style.finish(key), Truncated, f.path, fmt.Sprintf("from %d to %d bytes", len(s), f.opts.MaxValueLen))
+	}
+
+	return v, true
+}
+
+// shorten applies MaxKeyLen to the finished key, noting a shortened one in the trace.
+func (f *flattener) shorten(key string) (string, error) {
+	short, err := f.opts.shorten(key)
+	if err == nil && short != key && f.trace != nil {
+		f.trace.add(short, Shortened, f.path, fmt.Sprintf("from %d to %d bytes: %q", len(key), len(short), key))
+	}
+	return short, err
+}
+
+// A UTF8Policy says what to do with text which is not valid UTF-8, which strict JSON parsers reject.
+type UTF8Policy int
+
+const (
+	KeepInvalidUTF8    UTF8Policy = iota // Pass text through as it is
+	ReplaceInvalidUTF8                   // Replace each run of invalid bytes with the replacement rune, U+FFFD
+	RejectInvalidUTF8                    // Fail with an InvalidUTF8Error
+)
+
+// Text which is not valid UTF-8, under RejectInvalidUTF8
+var InvalidUTF8Error = errors.New("Not valid UTF-8")
+
+// text applies the UTF-8 policy to s, a map key or string value.
+func (o Options) text(s string) (string, error) {
+	if o.InvalidUTF8 == KeepInvalidUTF8 || utf8.ValidString(s) {
+		return s, nil
+	}
+	if o.InvalidUTF8 == RejectInvalidUTF8 {
+		return s, InvalidUTF8Error
+	}
+	return strings.ToValidUTF8(s, string(utf8.RuneError)), nil
+}
+
+// A BytesEncoding says how []byte values appear in the flat map, for outputs which would otherwise take
+// them for text.
+type BytesEncoding int
+
+const (
+	RawBytes    BytesEncoding = iota // Keep them as []byte
+	Base64Bytes                      // Encode them as standard, padded base64 strings
+	HexBytes                         // Encode them as lowercase hex strings
+	MarkBytes                        // Drop them, leaving a marker such as "<binary 16 bytes>"
+)
+
+func (e BytesEncoding) encode(b []byte) interface{} {
+	switch e {
+	case Base64Bytes:
+		return base64.StdEncoding.EncodeToString(b)
+	case HexBytes:
+		return hex.EncodeToString(b)
+	case MarkBytes:
+		return "<binary " + strconv.Itoa(len(b)) + " bytes>"
+	}
+	return b
+}
+
+// An IntermediatePolicy says whether flattening gives keys to the maps and slices it descends into, as
+// well as to leaves, e.g. for prefix indexes and existence checks.  Such output does not unflatten, its
+// keys being both values and parents.
+type IntermediatePolicy int
+
+const (
+	LeavesOnly        IntermediatePolicy = iota // Give keys to leaves alone
+	MarkIntermediates                           // Give keys to maps and slices too, valued by Options.IntermediateMark
+	RawIntermediates                            // Give keys to maps and slices too, valued by themselves, shared with the input
+)
+
+// intermediate returns the value at the key of the map or slice v, under the policy.
+func (o Options) intermediate(v interface{}) interface{} {
+	if o.Intermediates == RawIntermediates {
+		return v
+	}
+	if o.IntermediateMark == nil {
+		return true
+	}
+	return o.IntermediateMark
+}
+
+// A DepthPolicy says what flattening does with a map or slice beyond Options.MaxDepth.
+type DepthPolicy int
+
+const (
+	KeepOnDepth    DepthPolicy = iota // Keep it whole, as the value of its key
+	MarshalOnDepth                    // Keep it as a JSON string, for sinks which take only scalars
+	ErrorOnDepth                      // Fail with a *DepthError
+)
+
+// The input is nested beyond the depth limit, under ErrorOnDepth
+var MaxDepthExceededError = errors.New("Not a valid input: nested beyond the depth limit")
+
+// A DepthError reports a map or slice beyond the depth limit.  It is a MaxDepthExceededError, to
+// errors.Is.
+type DepthError struct {
+	Key      string // The flat key of the map or slice
+	MaxDepth int
+}
+
+func (e *DepthError) Error() string {
+	return fmt.Sprintf("%v of %d: at %q", MaxDepthExceededError, e.MaxDepth, e.Key)
+}
+
+func (e *DepthError) Unwrap() error {
+	return MaxDepthExceededError
+}
+
+// A KeyLenPolicy says what flattening does with a key longer than Options.MaxKeyLen.
+type KeyLenPolicy int
+
+const (
+	HashLongKeys    KeyLenPolicy = iota // Cut it short, ending in a hash of the whole key, so distinct keys stay distinct
+	ErrorOnLongKeys                     // Fail with a *KeyLenError
+)
+
+// The length of the hash ending a shortened key, in hex digits
+const keyHashLen = 8
+
+// A key is longer than the limit, under ErrorOnLongKeys
+var KeyTooLongError = errors.New("Not a valid input: key longer than the limit")
+
+// A KeyLenError reports a key longer than Options.MaxKeyLen.  It is a KeyTooLongError, to errors.Is.
+type KeyLenError struct {
+	Key       string
+	MaxKeyLen int
+}
+
+func (e *KeyLenError) Error() string {
+	return fmt.Sprintf("%v of %d: %q", KeyTooLongError, e.MaxKeyLen, e.Key)
+}
+
+func (e *KeyLenError) Unwrap() error {
+	return KeyTooLongError
+}
+
+// shorten applies MaxKeyLen to key.  Under HashLongKeys, a long key is cut, on a rune boundary, to leave
+// room for the FNV-1a hash of the whole, in hex, e.g. "a.very.long.key", under a limit of 14, becoming
+// "a.very47e5726b".  A limit too small for even the hash gives as much of the hash as fits.
+func (o Options) shorten(key string) (string, error) {
+	if o.MaxKeyLen <= 0 || len(key) <= o.MaxKeyLen {
+		return key, nil
+	}
+	if o.KeyOverflow == ErrorOnLongKeys {
+		return "", &KeyLenError{Key: key, MaxKeyLen: o.MaxKeyLen}
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	sum := fmt.Sprintf("%0*x", keyHashLen, h.Sum32())
+	if o.MaxKeyLen <= keyHashLen {
+		return sum[:o.MaxKeyLen], nil
+	}
+
+	head, _ := truncateUTF8(key, o.MaxKeyLen-keyHashLen)
+	return head + sum, nil
+}
+
+// The output has more keys than the limit
+var TooManyKeysError = errors.New("Not a valid input: more keys than the limit")
+
+// A KeyLimitError reports the key which would have taken the output beyond Options.MaxKeys.  It is a
+// TooManyKeysError, to errors.Is.
+type KeyLimitError struct {
+	Key     string // The first key beyond the limit, left out
+	MaxKeys int
+}
+
+func (e *KeyLimitError) Error() string {
+	return fmt.Sprintf("%v of %d: at %q", TooManyKeysError, e.MaxKeys, e.Key)
+}
+
+func (e *KeyLimitError) Unwrap() error {
+	return TooManyKeysError
+}
+
+// A NilPolicy says what FlattenValue makes of nil pointers and interfaces, e.g. an unset *TLSConfig field.
+type NilPolicy int
+
+const (
+	NilAsNull  NilPolicy = iota // Keep the key, with a nil value, as JSON has null
+	SkipNil                     // Leave the key out
+	NilAsEmpty                  // Keep the key, with an empty string value
+)
+
+// nilValue applies the policy to v, returning false if its key is to be left out.
+func (p NilPolicy) nilValue(v interface{}) (interface{}, bool) {
+	if p == NilAsNull || !isNilRef(v) {
+		return v, true
+	}
+	if p == SkipNil {
+		return nil, false
+	}
+	return "", true
+}
+
+// A TimeFormat says how time.Time values appear in the flat map, for sinks particular about timestamps.
+type TimeFormat int
+
+const (
+	RFC3339Time   TimeFormat = iota // As RFC 3339 strings, with fractional seconds as needed, as JSON has them
+	UnixTime                        // As int64 seconds since the Unix epoch
+	UnixMilliTime                   // As int64 milliseconds since the Unix epoch
+)
+
+// render presents t in the format, or returns false to leave it as JSON has it.
+func (tf TimeFormat) render(t time.Time) (interface{}, bool) {
+	switch tf {
+	case UnixTime:
+		return t.Unix(), true
+	case UnixMilliTime:
+		return t.UnixMilli(), true
+	}
+	return nil, false
+}
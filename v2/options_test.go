@@ -0,0 +1,597 @@
+package flatten
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestInvalidUTF8(t *testing.T) {
+	nested := map[string]interface{}{
+		"ok":       "fine",
+		"bad\xffk": map[string]interface{}{"v": "a\xfe\xffb"},
+	}
+
+	cases := []struct {
+		policy UTF8Policy
+		want   map[string]interface{}
+		err    error
+	}{
+		// 1
+		{
+			KeepInvalidUTF8,
+			map[string]interface{}{"ok": "fine", "bad\xffk.v": "a\xfe\xffb"},
+			nil,
+		},
+		// 2
+		{
+			ReplaceInvalidUTF8,
+			map[string]interface{}{"ok": "fine", "bad�k.v": "a�b"},
+			nil,
+		},
+		// 3
+		{
+			RejectInvalidUTF8,
+			nil,
+			InvalidUTF8Error,
+		},
+	}
+
+	for i, test := range cases {
+		got, err := Options{InvalidUTF8: test.policy}.Flatten(nested, "", DotStyle)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%d: error mismatch, got: [%v], wanted: [%v]", i+1, err, test.err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%d: mismatch, got: %q wanted: %q", i+1, got, test.want)
+		}
+	}
+}
+
+func TestInvalidUTF8ErrorPath(t *testing.T) {
+	nested := map[string]interface{}{"a": map[string]interface{}{"b": "\xff"}}
+
+	_, err := Options{InvalidUTF8: RejectInvalidUTF8}.Flatten(nested, "", DotStyle)
+	if err == nil || !strings.Contains(err.Error(), `"a.b"`) {
+		t.Errorf("error lacks the key: %v", err)
+	}
+}
+
+func TestBytesEncoding(t *testing.T) {
+	nested := map[string]interface{}{"b": []byte{0xde, 0xad, 0xbe, 0xef}, "s": "text"}
+
+	cases := []struct {
+		encoding BytesEncoding
+		want     interface{}
+	}{
+		// 1
+		{RawBytes, []byte{0xde, 0xad, 0xbe, 0xef}},
+		// 2
+		{Base64Bytes, "3q2+7w=="},
+		// 3
+		{HexBytes, "deadbeef"},
+		// 4
+		{MarkBytes, "<binary 4 bytes>"},
+	}
+
+	for i, test := range cases {
+		got, err := Options{Bytes: test.encoding}.Flatten(nested, "", DotStyle)
+		if err != nil {
+			t.Errorf("%d: failed to flatten: %v", i+1, err)
+			continue
+		}
+		want := map[string]interface{}{"b": test.want, "s": "text"}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%d: mismatch, got: %v wanted: %v", i+1, got, want)
+		}
+	}
+}
+
+func TestTimeFormat(t *testing.T) {
+	at := time.Date(2024, 5, 1, 10, 0, 0, 500e6, time.UTC)
+	nested := map[string]interface{}{"at": at, "ptr": &at, "s": "text"}
+
+	cases := []struct {
+		format TimeFormat
+		want   interface{}
+	}{
+		// 1
+		{RFC3339Time, "2024-05-01T10:00:00.5Z"},
+		// 2
+		{UnixTime, int64(1714557600)},
+		// 3
+		{UnixMilliTime, int64(1714557600500)},
+	}
+
+	for i, test := range cases {
+		got, err := Options{Times: test.format}.Flatten(nested, "", DotStyle)
+		if err != nil {
+			t.Errorf("%d: failed to flatten: %v", i+1, err)
+			continue
+		}
+		want := map[string]interface{}{"at": test.want, "ptr": test.want, "s": "text"}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%d: mismatch, got: %v wanted: %v", i+1, got, want)
+		}
+	}
+
+	got, err := Options{Times: UnixTime}.FlattenValue(struct{ At time.Time }{at}, "", DotStyle)
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+	if want := map[string]interface{}{"At": int64(1714557600)}; !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %v wanted: %v", got, want)
+	}
+}
+
+func TestMaxDepth(t *testing.T) {
+	nested := map[string]interface{}{
+		"a": map[string]interface{}{"b": map[string]interface{}{"c": 1.0}, "l": []interface{}{"x"}},
+		"s": "top",
+	}
+
+	cases := []struct {
+		opts Options
+		want map[string]interface{}
+	}{
+		// 1
+		{Options{}, map[string]interface{}{"a.b.c": 1.0, "a.l.0": "x", "s": "top"}},
+		// 2
+		{
+			Options{MaxDepth: 2},
+			map[string]interface{}{"a.b": map[string]interface{}{"c": 1.0}, "a.l": []interface{}{"x"}, "s": "top"},
+		},
+		// 3
+		{Options{MaxDepth: 2, DepthOverflow: MarshalOnDepth}, map[string]interface{}{"a.b": `{"c":1}`, "a.l": `["x"]`, "s": "top"}},
+		// 4
+		{Options{MaxDepth: 1, DepthOverflow: MarshalOnDepth}, map[string]interface{}{"a": `{"b":{"c":1},"l":["x"]}`, "s": "top"}},
+		// 5
+		{Options{MaxDepth: 3, DepthOverflow: ErrorOnDepth}, map[string]interface{}{"a.b.c": 1.0, "a.l.0": "x", "s": "top"}},
+	}
+
+	for i, test := range cases {
+		got, err := test.opts.Flatten(nested, "", DotStyle)
+		if err != nil {
+			t.Errorf("%d: failed to flatten: %v", i+1, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%d: mismatch, got: %v wanted: %v", i+1, got, test.want)
+		}
+	}
+
+	var sm sync.Map
+	sm.Store("k", 1.0)
+	sm.Store("m", map[interface{}]interface{}{"x": newOrderedMap("y", true)})
+	got, err := Options{MaxDepth: 1, DepthOverflow: MarshalOnDepth}.Flatten(map[string]interface{}{"s": &sm}, "", DotStyle)
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+	if want := map[string]interface{}{"s": `{"k":1,"m":{"x":{"y":true}}}`}; !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %v wanted: %v", got, want)
+	}
+
+	_, err = Options{MaxDepth: 2, DepthOverflow: ErrorOnDepth}.Flatten(map[string]interface{}{"a": nested["a"]}, "", DotStyle)
+	var depthErr *DepthError
+	if !errors.As(err, &depthErr) || !errors.Is(err, MaxDepthExceededError) || depthErr.MaxDepth != 2 || !strings.HasPrefix(depthErr.Key, "a.") {
+		t.Errorf("error mismatch, got: [%v], wanted a DepthError under \"a\"", err)
+	}
+}
+
+func TestMaxKeys(t *testing.T) {
+	list := make([]interface{}, 1000)
+	nested := map[string]interface{}{"a": 1.0, "l": list}
+
+	got, err := Options{MaxKeys: 1001}.Flatten(nested, "", DotStyle)
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+	if len(got) != 1001 {
+		t.Errorf("mismatch, got: %d keys wanted: 1001", len(got))
+	}
+
+	_, err = Options{MaxKeys: 100}.Flatten(nested, "", DotStyle)
+	var limitErr *KeyLimitError
+	if !errors.As(err, &limitErr) || !errors.Is(err, TooManyKeysError) || limitErr.MaxKeys != 100 || limitErr.Key == "" {
+		t.Errorf("error mismatch, got: [%v], wanted a KeyLimitError", err)
+	}
+
+	_, err = FlattenWithOptions(nested, MaxKeys(10))
+	if !errors.Is(err, TooManyKeysError) {
+		t.Errorf("error mismatch, got: [%v], wanted a TooManyKeysError", err)
+	}
+}
+
+func TestFlattenPartialOptions(t *testing.T) {
+	nested := map[string]interface{}{"l": []interface{}{"a", "b", "c", "d"}}
+
+	got, err := Options{MaxKeys: 2}.FlattenPartial(nested, "", DotStyle)
+	if !errors.Is(err, TooManyKeysError) {
+		t.Errorf("error mismatch, got: [%v], wanted: [%v]", err, TooManyKeysError)
+	}
+	if want := map[string]interface{}{"l.0": "a", "l.1": "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %v wanted: %v", got, want)
+	}
+
+	deep := map[string]interface{}{"l": []interface{}{"x", map[string]interface{}{"b": 1.0}}} // slices go by index
+	got, err = Options{MaxDepth: 2, DepthOverflow: ErrorOnDepth}.FlattenPartial(deep, "", DotStyle)
+	var depthErr *DepthError
+	if !errors.As(err, &depthErr) {
+		t.Errorf("error mismatch, got: [%v], wanted a DepthError", err)
+	}
+	if want := map[string]interface{}{"l.0": "x"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %v wanted: %v", got, want)
+	}
+}
+
+func TestMaxKeyLen(t *testing.T) {
+	nested := map[string]interface{}{
+		"a": map[string]interface{}{"very": map[string]interface{}{"long": map[string]interface{}{"key": 1.0, "kez": 2.0}}},
+		"b": 3.0,
+	}
+
+	cases := []struct {
+		opts Options
+		want map[string]interface{}
+	}{
+		// 1
+		{Options{}, map[string]interface{}{"a.very.long.key": 1.0, "a.very.long.kez": 2.0, "b": 3.0}},
+		// 2 -- distinct keys stay distinct by their hashes
+		{Options{MaxKeyLen: 14}, map[string]interface{}{"a.very47e5726b": 1.0, "a.very48e573fe": 2.0, "b": 3.0}},
+		// 3
+		{Options{MaxKeyLen: 15}, map[string]interface{}{"a.very.long.key": 1.0, "a.very.long.kez": 2.0, "b": 3.0}},
+		// 4 -- room for part of the hash alone
+		{Options{MaxKeyLen: 4}, map[string]interface{}{"47e5": 1.0, "48e5": 2.0, "b": 3.0}},
+	}
+
+	for i, test := range cases {
+		got, err := test.opts.Flatten(nested, "", DotStyle)
+		if err != nil {
+			t.Errorf("%d: failed to flatten: %v", i+1, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%d: mismatch, got: %v wanted: %v", i+1, got, test.want)
+		}
+	}
+
+	_, err := Options{MaxKeyLen: 14, KeyOverflow: ErrorOnLongKeys}.Flatten(nested, "", DotStyle)
+	var lenErr *KeyLenError
+	if !errors.As(err, &lenErr) || !errors.Is(err, KeyTooLongError) || lenErr.MaxKeyLen != 14 || !strings.HasPrefix(lenErr.Key, "a.very.long.") {
+		t.Errorf("error mismatch, got: [%v], wanted a KeyLenError", err)
+	}
+
+	var trace Trace
+	if _, err := FlattenWithOptions(nested, MaxKeyLen(14, HashLongKeys), WithTrace(&trace)); err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+	if s := trace.String(); !strings.Contains(s, "a.very47e5726b: shortened") {
+		t.Errorf("mismatch, got: %q wanted a shortened decision", s)
+	}
+}
+
+func TestIntermediates(t *testing.T) {
+	nested := map[string]interface{}{
+		"a": map[string]interface{}{"b": map[string]interface{}{"c": 1.0}, "l": []interface{}{"x"}},
+		"e": map[string]interface{}{},
+		"s": "top",
+	}
+
+	cases := []struct {
+		opts Options
+		want map[string]interface{}
+	}{
+		// 1
+		{Options{}, map[string]interface{}{"a.b.c": 1.0, "a.l.0": "x", "s": "top"}},
+		// 2 -- empty maps are nodes too
+		{
+			Options{Intermediates: MarkIntermediates},
+			map[string]interface{}{"a": true, "a.b": true, "a.b.c": 1.0, "a.l": true, "a.l.0": "x", "e": true, "s": "top"},
+		},
+		// 3
+		{
+			Options{Intermediates: MarkIntermediates, IntermediateMark: "{}"},
+			map[string]interface{}{"a": "{}", "a.b": "{}", "a.b.c": 1.0, "a.l": "{}", "a.l.0": "x", "e": "{}", "s": "top"},
+		},
+		// 4
+		{
+			Options{Intermediates: RawIntermediates},
+			map[string]interface{}{
+				"a": nested["a"], "a.b": map[string]interface{}{"c": 1.0}, "a.b.c": 1.0,
+				"a.l": []interface{}{"x"}, "a.l.0": "x", "e": map[string]interface{}{}, "s": "top",
+			},
+		},
+		// 5 -- maps kept whole at the depth limit are leaves
+		{
+			Options{Intermediates: MarkIntermediates, MaxDepth: 2},
+			map[string]interface{}{"a": true, "a.b": map[string]interface{}{"c": 1.0}, "a.l": []interface{}{"x"}, "e": true, "s": "top"},
+		},
+	}
+
+	for i, test := range cases {
+		got, err := test.opts.Flatten(nested, "", DotStyle)
+		if err != nil {
+			t.Errorf("%d: failed to flatten: %v", i+1, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%d: mismatch, got: %v wanted: %v", i+1, got, test.want)
+		}
+	}
+}
+
+func TestKeepEmpty(t *testing.T) {
+	nested := map[string]interface{}{
+		"a": map[string]interface{}{},
+		"b": []interface{}{},
+		"c": map[string]interface{}{"d": []interface{}{map[string]interface{}{}}},
+		"e": 1.0,
+	}
+
+	got, err := Options{}.Flatten(nested, "", DotStyle)
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+	if want := map[string]interface{}{"e": 1.0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %v wanted: %v", got, want)
+	}
+
+	got, err = Options{KeepEmpty: true}.Flatten(nested, "", DotStyle)
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+	want := map[string]interface{}{"a": map[string]interface{}{}, "b": []interface{}{}, "c.d.0": map[string]interface{}{}, "e": 1.0}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %v wanted: %v", got, want)
+	}
+
+	back, err := Unflatten(got, DotStyle)
+	if err != nil {
+		t.Fatalf("failed to unflatten: %v", err)
+	}
+	if !reflect.DeepEqual(back, nested) {
+		t.Errorf("round trip mismatch, got: %v wanted: %v", back, nested)
+	}
+
+	var sm sync.Map
+	got, err = Options{KeepEmpty: true}.Flatten(map[string]interface{}{"s": &sm}, "", DotStyle)
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+	if want := map[string]interface{}{"s": map[string]interface{}{}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %v wanted: %v", got, want)
+	}
+}
+
+func TestKeepArrays(t *testing.T) {
+	nested := map[string]interface{}{
+		"tags": []string{"a", "b"},
+		"doc":  map[string]interface{}{"ids": []interface{}{1.0, 2.0}, "owner": map[string]interface{}{"name": "x"}},
+		"refs": []interface{}{map[string]interface{}{"k": "v"}},
+	}
+
+	got, err := Options{KeepArrays: true}.Flatten(nested, "", DotStyle)
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+	want := map[string]interface{}{
+		"tags":           []interface{}{"a", "b"},
+		"doc.ids":        []interface{}{1.0, 2.0},
+		"doc.owner.name": "x",
+		"refs":           []interface{}{map[string]interface{}{"k": "v"}}, // left as they are within
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %v wanted: %v", got, want)
+	}
+
+	flat, err := Options{KeepArrays: true}.FlattenString(`{"a":{"b":[1,2]},"c":[]}`, "", DotStyle)
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+	if want := `{"a.b":[1,2],"c":[]}`; flat != want {
+		t.Errorf("mismatch, got: %v wanted: %v", flat, want)
+	}
+}
+
+func TestIndexBase(t *testing.T) {
+	nested := map[string]interface{}{"a": []interface{}{"x", []interface{}{"y", "z"}}, "m": map[string]interface{}{"k": "v"}}
+
+	cases := []struct {
+		base  int
+		style SeparatorStyle
+		want  map[string]interface{}
+	}{
+		// 1
+		{0, DotStyle, map[string]interface{}{"a.0": "x", "a.1.0": "y", "a.1.1": "z", "m.k": "v"}},
+		// 2
+		{1, DotStyle, map[string]interface{}{"a.1": "x", "a.2.1": "y", "a.2.2": "z", "m.k": "v"}},
+		// 3
+		{1, SpringStyle, map[string]interface{}{"a[1]": "x", "a[2][1]": "y", "a[2][2]": "z", "m.k": "v"}},
+	}
+
+	for i, test := range cases {
+		o := Options{IndexBase: test.base}
+		got, err := o.Flatten(nested, "", test.style)
+		if err != nil {
+			t.Errorf("%d: failed to flatten: %v", i+1, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%d: mismatch, got: %v wanted: %v", i+1, got, test.want)
+		}
+
+		back, err := o.Unflatten(got, test.style)
+		if err != nil {
+			t.Errorf("%d: failed to unflatten: %v", i+1, err)
+			continue
+		}
+		if !reflect.DeepEqual(back, nested) {
+			t.Errorf("%d: round trip mismatch, got: %v wanted: %v", i+1, back, nested)
+		}
+	}
+}
+
+func TestIndexWidth(t *testing.T) {
+	list := make([]interface{}, 12)
+	for i := range list {
+		list[i] = float64(i)
+	}
+	nested := map[string]interface{}{"a": list, "b": []interface{}{"x"}}
+
+	o := Options{IndexWidth: 3}
+	got, err := Flatten(nested, "", DotStyle)
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+	if keys := sortedKeys(got); keys[2] != "a.10" {
+		t.Errorf("mismatch, got: %v wanted a.10 third, as unpadded keys sort", keys)
+	}
+
+	got, err = o.Flatten(nested, "", DotStyle)
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+	keys := sortedKeys(got)
+	if keys[2] != "a.002" || keys[10] != "a.010" || keys[12] != "b.000" {
+		t.Errorf("mismatch, got: %v wanted padded keys in index order", keys)
+	}
+
+	got, err = Options{IndexWidth: 2, IndexBase: 1}.Flatten(map[string]interface{}{"l": list[:10]}, "", DotStyle)
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+	if _, ok := got["l.01"]; !ok || got["l.10"] != 9.0 {
+		t.Errorf("mismatch, got: %v wanted l.01 through l.10", got)
+	}
+
+	flat, err := o.Flatten(nested, "", DotStyle)
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+	back, err := o.Unflatten(flat, DotStyle)
+	if err != nil {
+		t.Fatalf("failed to unflatten: %v", err)
+	}
+	if !reflect.DeepEqual(back, nested) {
+		t.Errorf("round trip mismatch, got: %v wanted: %v", back, nested)
+	}
+}
+
+func TestMaxValueLen(t *testing.T) {
+	nested := map[string]interface{}{
+		"short": "abc",
+		"log":   map[string]interface{}{"body": "0123456789"},
+		"n":     12345678901.0,
+	}
+
+	cases := []struct {
+		opts Options
+		want map[string]interface{}
+	}{
+		// 1
+		{
+			Options{MaxValueLen: 8},
+			map[string]interface{}{"short": "abc", "log.body": "01234...", "n": 12345678901.0},
+		},
+		// 2
+		{
+			Options{MaxValueLen: 8, Ellipsis: "…", MarkTruncated: true},
+			map[string]interface{}{"short": "abc", "log.body": "01234…", "log.body.__truncated": true, "n": 12345678901.0},
+		},
+		// 3 -- a limit within the ellipsis
+		{
+			Options{MaxValueLen: 2},
+			map[string]interface{}{"short": "ab", "log.body": "01", "n": 12345678901.0},
+		},
+	}
+
+	for i, test := range cases {
+		got, err := test.opts.Flatten(nested, "", DotStyle)
+		if err != nil {
+			t.Errorf("%d: failed to flatten: %v", i+1, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%d: mismatch, got: %v wanted: %v", i+1, got, test.want)
+		}
+	}
+}
+
+func TestMarkTruncatedKeyLen(t *testing.T) {
+	nested := map[string]interface{}{"abcdefghij": "0123456789"}
+
+	_, err := Options{MaxValueLen: 5, MarkTruncated: true, MaxKeyLen: 12, KeyOverflow: ErrorOnLongKeys}.Flatten(nested, "", DotStyle)
+	var lenErr *KeyLenError
+	if !errors.As(err, &lenErr) || lenErr.Key != "abcdefghij.__truncated" {
+		t.Errorf("error mismatch, got: [%v], wanted a KeyLenError for the marker", err)
+	}
+
+	got, err := Options{MaxValueLen: 5, MarkTruncated: true, MaxKeyLen: 12}.Flatten(nested, "", DotStyle)
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+	if len(got) != 2 || got["abcdefghij"] != "01..." {
+		t.Errorf("mismatch, got: %v wanted the value and a marker", got)
+	}
+	for k := range got {
+		if len(k) > 12 {
+			t.Errorf("key %q longer than the limit", k)
+		}
+	}
+
+	if _, err := (Options{MaxValueLen: 5, MarkTruncated: true, MaxKeys: 1}).Flatten(nested, "", DotStyle); !errors.Is(err, TooManyKeysError) {
+		t.Errorf("error mismatch, got: [%v], wanted: [%v]", err, TooManyKeysError)
+	}
+}
+
+func TestJSONC(t *testing.T) {
+	src := `{
+		// the server
+		"server": { "port": 8080, /* default */ "hosts": ["a", "b",], },
+	}`
+
+	got, err := Options{JSONC: true}.FlattenString(src, "", DotStyle)
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+	if want := `{"server.hosts.0":"a","server.hosts.1":"b","server.port":8080}`; got != want {
+		t.Errorf("mismatch, got: %v wanted: %v", got, want)
+	}
+
+	if _, err := (Options{}).FlattenString(src, "", DotStyle); err == nil {
+		t.Errorf("strict: comments accepted")
+	}
+}
+
+func TestUseNumber(t *testing.T) {
+	src := `{"id": 1234567890123456789, "ratio": 0.1, "n": [1e3]}`
+
+	cases := []struct {
+		opts Options
+		want string
+	}{
+		// 1
+		{Options{UseNumber: true}, `{"id":1234567890123456789,"n.0":1e3,"ratio":0.1}`},
+		// 2
+		{Options{}, `{"id":1234567890123456800,"n.0":1000,"ratio":0.1}`},
+	}
+
+	for i, test := range cases {
+		got, err := test.opts.FlattenString(src, "", DotStyle)
+		if err != nil {
+			t.Errorf("%d: failed to flatten: %v", i+1, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%d: mismatch, got: %v wanted: %v", i+1, got, test.want)
+		}
+	}
+
+	if _, err := (Options{UseNumber: true}).FlattenString(`{"a": 1} x`, "", DotStyle); err == nil {
+		t.Errorf("trailing data accepted")
+	}
+}
@@ -0,0 +1,46 @@
+package flatten
+
+// An OrderedMap is a map keeping the order of its keys, as YAML and JSON libraries often provide.
+// OrderedMaps may stand wherever maps do in nested input, and are visited in their order.
+type OrderedMap interface {
+	Keys() []string
+	Get(key string) (interface{}, bool)
+}
+
+// A Pair is one flattened key and value.
+type Pair struct {
+	Key   string
+	Value interface{}
+}
+
+// FlattenOrdered is Flatten, returning pairs in the order of the input: OrderedMaps in their order, slices
+// by index, and plain maps by sorted key.  The nested input is a map, an OrderedMap, or a slice.  Where
+// keys repeat, the pair stands where the key first appeared, with the value it was given last.
+func FlattenOrdered(nested interface{}, prefix string, style SeparatorStyle) ([]Pair, error) {
+	size := 0
+	switch n := nested.(type) {
+	case map[string]interface{}:
+		size = len(n)
+	case OrderedMap:
+		size = len(n.Keys())
+	case []interface{}:
+		size = len(n)
+	}
+	done := observe("FlattenOrdered", size)
+	flatmap := make(map[string]interface{})
+	var order []string
+
+	f := flattener{style: style, order: &order}
+	err := f.flatten(true, flatmap, nested, prefix, 1)
+	done(len(flatmap), err)
+	if err != nil {
+		return nil, err
+	}
+
+	pairs := make([]Pair, len(order))
+	for i, k := range order {
+		pairs[i] = Pair{k, flatmap[k]}
+	}
+
+	return pairs, nil
+}
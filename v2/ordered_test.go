@@ -0,0 +1,67 @@
+package flatten
+
+import (
+	"reflect"
+	"testing"
+)
+
+// orderedMap is a minimal OrderedMap, keeping keys in insertion order.
+type orderedMap struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func newOrderedMap(kvs ...interface{}) *orderedMap {
+	m := &orderedMap{values: map[string]interface{}{}}
+	for i := 0; i < len(kvs); i += 2 {
+		m.keys = append(m.keys, kvs[i].(string))
+		m.values[kvs[i].(string)] = kvs[i+1]
+	}
+	return m
+}
+
+func (m *orderedMap) Keys() []string { return m.keys }
+
+func (m *orderedMap) Get(key string) (interface{}, bool) {
+	v, ok := m.values[key]
+	return v, ok
+}
+
+func TestFlattenOrdered(t *testing.T) {
+	nested := newOrderedMap(
+		"zeta", "z",
+		"alpha", newOrderedMap("y", 1, "x", 2),
+		"list", []interface{}{"a", map[string]interface{}{"d": 4, "c": 3}},
+	)
+
+	got, err := FlattenOrdered(nested, "", DotStyle)
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+	want := []Pair{
+		{"zeta", "z"},
+		{"alpha.y", 1},
+		{"alpha.x", 2},
+		{"list.0", "a"},
+		{"list.1.c", 3},
+		{"list.1.d", 4},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %v wanted: %v", got, want)
+	}
+
+	if _, err := FlattenOrdered("scalar", "", DotStyle); err != NotValidInputError {
+		t.Errorf("error mismatch, got: [%v], wanted: [%v]", err, NotValidInputError)
+	}
+}
+
+func TestFlattenOrderedMapValues(t *testing.T) {
+	got, err := Flatten(map[string]interface{}{"m": newOrderedMap("b", true)}, "", DotStyle)
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+	want := map[string]interface{}{"m.b": true}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %v wanted: %v", got, want)
+	}
+}
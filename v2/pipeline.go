@@ -0,0 +1,53 @@
+package flatten
+
+// A Pipeline flattens a nested map in a single traversal, passing each value through its stages on the
+// way: Filter, then Rename, Redact and Transform.  Every stage is optional.
+//
+// Each stage is given the path to the value, as map keys (strings) and slice indexes (ints) of the
+// original, unrenamed document.  The path is reused as the traversal goes on, so copy it to keep it.
+type Pipeline struct {
+	Filter    func(path []interface{}, v interface{}) bool        // Keep a value, or drop it (maps and slices included)
+	Rename    func(path []interface{}, key string) string         // Rewrite a map key, before styling
+	Redact    func(path []interface{}, v interface{}) bool        // Replace a value, or a whole map or slice, with Redaction
+	Transform func(path []interface{}, v interface{}) interface{} // Rewrite a scalar value
+
+	Redaction interface{}    // The replacement for redacted values, e.g. "[REDACTED]"
+	Prefix    string         // Joined to each key
+	Style     SeparatorStyle // The presentation of keys
+}
+
+// Flatten generates a flat map from a nested one, as the package-level Flatten does, running each value
+// through the pipeline's stages.
+func (p Pipeline) Flatten(nested map[string]interface{}) (map[string]interface{}, error) {
+	return settings{prefix: p.Prefix, style: p.Style, pipeline: &p}.flatten("Pipeline.Flatten", nested)
+}
+
+// keep applies the pipeline's Filter, if any, to the value at the current path.
+func (f *flattener) keep(v interface{}) bool {
+	return f.pipeline == nil || f.pipeline.Filter == nil || f.pipeline.Filter(f.path, v)
+}
+
+// rename applies the pipeline's Rename, if any, to the map key at the current path.
+func (f *flattener) rename(key string) string {
+	if f.pipeline == nil || f.pipeline.Rename == nil {
+		return key
+	}
+	return f.pipeline.Rename(f.path, key)
+}
+
+// pipe applies the pipeline's Redact and Transform, if any, to the value at the current path.
+func (f *flattener) pipe(v interface{}) interface{} {
+	p := f.pipeline
+	if p.Redact != nil && p.Redact(f.path, v) {
+		return p.Redaction
+	}
+
+	switch v.(type) {
+	case map[string]interface{}, []interface{}, OrderedMap, Ranger, anyRanger:
+		return v
+	}
+	if p.Transform != nil {
+		return p.Transform(f.path, v)
+	}
+	return v
+}
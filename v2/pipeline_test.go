@@ -0,0 +1,73 @@
+package flatten
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPipeline(t *testing.T) {
+	nested := map[string]interface{}{
+		"level": "info",
+		"msg":   "login",
+		"debug": map[string]interface{}{"trace": "abc"},
+		"user": map[string]interface{}{
+			"Name":     "ann",
+			"password": "hunter2",
+		},
+		"tags": []interface{}{"a", "b"},
+		"auth": map[string]interface{}{"token": "t", "scheme": "bearer"},
+	}
+
+	p := Pipeline{
+		Filter: func(path []interface{}, v interface{}) bool {
+			return path[0] != "debug"
+		},
+		Rename: func(path []interface{}, key string) string {
+			return strings.ToLower(key)
+		},
+		Redact: func(path []interface{}, v interface{}) bool {
+			last := path[len(path)-1]
+			return last == "password" || last == "auth"
+		},
+		Transform: func(path []interface{}, v interface{}) interface{} {
+			if s, ok := v.(string); ok && path[0] == "tags" {
+				return strings.ToUpper(s)
+			}
+			return v
+		},
+		Redaction: "[REDACTED]",
+		Prefix:    "log.",
+		Style:     DotStyle,
+	}
+
+	got, err := p.Flatten(nested)
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+	want := map[string]interface{}{
+		"log.level":         "info",
+		"log.msg":           "login",
+		"log.user.name":     "ann",
+		"log.user.password": "[REDACTED]",
+		"log.tags.0":        "A",
+		"log.tags.1":        "B",
+		"log.auth":          "[REDACTED]",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %v wanted: %v", got, want)
+	}
+}
+
+func TestPipelineWithoutStages(t *testing.T) {
+	nested := map[string]interface{}{"a": map[string]interface{}{"b": "c"}, "d": []interface{}{1.0}}
+
+	got, err := Pipeline{Style: DotStyle}.Flatten(nested)
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+	want, _ := Flatten(nested, "", DotStyle)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %v wanted: %v", got, want)
+	}
+}
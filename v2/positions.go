@@ -0,0 +1,125 @@
+package flatten
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// A Position locates a value in JSON source text.
+type Position struct {
+	Offset int // Byte offset, from 0
+	Line   int // Line, from 1
+	Column int // Byte column, from 1
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+// FlattenStringPositions is FlattenString, also returning where in nestedstr the value of each
+// flattened key starts, so that problems found with flat keys can be pointed out in the source.
+func FlattenStringPositions(nestedstr, prefix string, style SeparatorStyle) (string, map[string]Position, error) {
+	if !isJsonMap.MatchString(nestedstr) {
+		return "", nil, NotValidJsonInputError
+	}
+
+	p := positioner{
+		src:       nestedstr,
+		dec:       json.NewDecoder(strings.NewReader(nestedstr)),
+		style:     style,
+		flat:      make(map[string]interface{}),
+		positions: make(map[string]Position),
+	}
+	for i := 0; i < len(nestedstr); i++ {
+		if nestedstr[i] == '\n' {
+			p.lines = append(p.lines, i+1)
+		}
+	}
+
+	if _, err := p.dec.Token(); err != nil { // the opening brace
+		return "", nil, err
+	}
+	if err := p.container(json.Delim('{'), true, prefix); err != nil {
+		return "", nil, err
+	}
+	if _, err := p.dec.Token(); err != io.EOF {
+		if err == nil {
+			err = fmt.Errorf("invalid data after top-level value, at offset %d", p.dec.InputOffset())
+		}
+		return "", nil, err
+	}
+
+	flatb, err := json.Marshal(&p.flat)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return string(flatb), p.positions, nil
+}
+
+// positioner flattens JSON token by token, noting where each leaf starts.
+type positioner struct {
+	src       string
+	dec       *json.Decoder
+	lines     []int // Offsets at which lines after the first start
+	style     SeparatorStyle
+	flat      map[string]interface{}
+	positions map[string]Position
+}
+
+// container flattens the members of an object or array, whose opening delim has been read.
+func (p *positioner) container(delim json.Delim, top bool, prefix string) error {
+	for i := 0; p.dec.More(); i++ {
+		var key string
+		if delim == '{' {
+			tok, err := p.dec.Token()
+			if err != nil {
+				return err
+			}
+			key = enkey(top, prefix, tok.(string), p.style)
+		} else {
+			key = enindex(top, prefix, i, p.style)
+		}
+
+		start := p.next()
+		tok, err := p.dec.Token()
+		if err != nil {
+			return err
+		}
+
+		if d, ok := tok.(json.Delim); ok {
+			if err := p.container(d, false, key); err != nil {
+				return err
+			}
+			continue
+		}
+
+		key = p.style.finish(key)
+		p.flat[key] = tok
+		p.positions[key] = p.position(start)
+	}
+
+	_, err := p.dec.Token() // the closing delim
+	return err
+}
+
+// next finds the offset of the next token, past whitespace and separators.
+func (p *positioner) next() int {
+	off := int(p.dec.InputOffset())
+	for off < len(p.src) && strings.IndexByte(" \t\r\n:,", p.src[off]) >= 0 {
+		off++
+	}
+	return off
+}
+
+func (p *positioner) position(off int) Position {
+	line := sort.SearchInts(p.lines, off+1) // lines starting at or before off
+	start := 0
+	if line > 0 {
+		start = p.lines[line-1]
+	}
+	return Position{Offset: off, Line: line + 1, Column: off - start + 1}
+}
@@ -0,0 +1,75 @@
+package flatten
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFlattenStringPositions(t *testing.T) {
+	src := `{
+  "a": { "b": "c" },
+  "list": [
+    1,
+    { "d": true }
+  ],
+  "n": null
+}`
+
+	got, positions, err := FlattenStringPositions(src, "", DotStyle)
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+
+	if want := `{"a.b":"c","list.0":1,"list.1.d":true,"n":null}`; got != want {
+		t.Errorf("mismatch, got: %v wanted: %v", got, want)
+	}
+
+	wantPositions := map[string]Position{
+		"a.b":      {Offset: 16, Line: 2, Column: 15},
+		"list.0":   {Offset: 39, Line: 4, Column: 5},
+		"list.1.d": {Offset: 53, Line: 5, Column: 12},
+		"n":        {Offset: 72, Line: 7, Column: 8},
+	}
+	if !reflect.DeepEqual(positions, wantPositions) {
+		t.Errorf("positions mismatch, got: %+v wanted: %+v", positions, wantPositions)
+	}
+}
+
+func TestFlattenStringPositionsMatchesFlattenString(t *testing.T) {
+	cases := []string{
+		`{ "a": { "b" : { "c" : { "d" : "e" } } }, "number": 1.4567, "bool": true }`,
+		`{ "a": [], "b": {}, "c": [[1, [2]], {"x": {"y": "z"}}] }`,
+		`{ "dup": 1, "dup": 2 }`,
+	}
+
+	for i, src := range cases {
+		want, _ := FlattenString(src, "p:", RailsStyle)
+		got, _, err := FlattenStringPositions(src, "p:", RailsStyle)
+		if err != nil {
+			t.Errorf("%d: failed to flatten: %v", i+1, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("%d: mismatch, got: %v wanted: %v", i+1, got, want)
+		}
+	}
+}
+
+func TestFlattenStringPositionsErrors(t *testing.T) {
+	cases := []struct {
+		src string
+		err error
+	}{
+		{`[1]`, NotValidJsonInputError},
+		{`{"a": }`, nil},
+		{`{"a": 1} x`, nil},
+		{`{"a": 1`, nil},
+	}
+
+	for i, test := range cases {
+		_, _, err := FlattenStringPositions(test.src, "", DotStyle)
+		if err == nil || test.err != nil && err != test.err {
+			t.Errorf("%d: error mismatch, got: [%v], wanted: [%v]", i+1, err, test.err)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package flatten
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf16"
+)
+
+// FlattenToProperties flattens nested and writes it to w as a Java .properties file, one key=value line
+// per key, sorted.  Keys and values are escaped as java.util.Properties stores them: backslashes, "=",
+// ":", "#", "!" and whitespace by backslash, and characters outside printable ASCII as \uXXXX, so the
+// file is plain ASCII.  INI readers taking key=value lines without sections read it as well.
+func FlattenToProperties(nested map[string]interface{}, style SeparatorStyle, w io.Writer) error {
+	flat, err := Flatten(nested, "", style)
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	for _, k := range sortedKeys(flat) {
+		fmt.Fprintf(bw, "%s=%s\n", propertiesEscape(k, true), propertiesEscape(formatValue(flat[k]), false))
+	}
+
+	return bw.Flush()
+}
+
+// propertiesEscape escapes a key, with all its spaces, or a value, with its leading ones.
+func propertiesEscape(s string, key bool) string {
+	var b strings.Builder
+	for i, r := range s {
+		switch r {
+		case ' ':
+			if key || i == 0 {
+				b.WriteByte('\\')
+			}
+			b.WriteByte(' ')
+		case '\\', '=', ':', '#', '!':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\f':
+			b.WriteString(`\f`)
+		default:
+			if r < 0x20 || r > 0x7e {
+				for _, u := range utf16.Encode([]rune{r}) {
+					fmt.Fprintf(&b, `\u%04X`, u)
+				}
+				continue
+			}
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,53 @@
+package flatten
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFlattenToProperties(t *testing.T) {
+	cases := []struct {
+		nested map[string]interface{}
+		style  SeparatorStyle
+		want   string
+	}{
+		// 1
+		{
+			map[string]interface{}{
+				"server": map[string]interface{}{"port": 8080.0, "hosts": []interface{}{"a", "b"}},
+				"debug":  false,
+			},
+			DotStyle,
+			"debug=false\nserver.hosts.0=a\nserver.hosts.1=b\nserver.port=8080\n",
+		},
+		// 2 -- separators and whitespace
+		{
+			map[string]interface{}{"a=b:c": "x=y:z #! w", "a key": "  lead\ttab\nline"},
+			DotStyle,
+			"a\\ key=\\  lead\\ttab\\nline\na\\=b\\:c=x\\=y\\:z \\#\\! w\n",
+		},
+		// 3 -- unicode and backslashes
+		{
+			map[string]interface{}{`C:\dir`: "café €😀"},
+			PathStyle,
+			`C\:\\dir=caf\u00E9 \u20AC\uD83D\uDE00` + "\n",
+		},
+		// 4
+		{
+			map[string]interface{}{"a": nil},
+			DotStyle,
+			"a=\n",
+		},
+	}
+
+	for i, test := range cases {
+		var b strings.Builder
+		if err := FlattenToProperties(test.nested, test.style, &b); err != nil {
+			t.Errorf("%d: failed to write: %v", i+1, err)
+			continue
+		}
+		if got := b.String(); got != test.want {
+			t.Errorf("%d: mismatch, got: %q wanted: %q", i+1, got, test.want)
+		}
+	}
+}
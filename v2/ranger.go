@@ -0,0 +1,57 @@
+package flatten
+
+// A Ranger is a map which visits its pairs itself, as concurrent maps and some ordered map libraries do,
+// until fn returns false.  Rangers, and maps ranging as sync.Map does, with keys of any type, may stand
+// wherever maps do in nested input, and are flattened without first being copied.
+type Ranger interface {
+	Range(fn func(key string, v interface{}) bool)
+}
+
+// An anyRanger visits its pairs as sync.Map does
+type anyRanger interface {
+	Range(fn func(key, v interface{}) bool)
+}
+
+// rangeOf returns the visit of a Ranger or anyRanger, with the keys of the latter which are not strings
+// formatted.
+func (f *flattener) rangeOf(v interface{}) func(fn func(key string, v interface{}) bool) {
+	switch r := v.(type) {
+	case Ranger:
+		return r.Range
+	case anyRanger:
+		return func(fn func(key string, v interface{}) bool) {
+			r.Range(func(k, v interface{}) bool {
+				s, ok := k.(string)
+				if !ok {
+					s = f.opts.formatKey(k)
+				}
+				return fn(s, v)
+			})
+		}
+	}
+	return nil
+}
+
+// rangeMembers visits the pairs of a ranging map with member, by sorted key if the flattener sorts.
+func (f *flattener) rangeMembers(each func(fn func(key string, v interface{}) bool), member func(k string, v interface{}) error) error {
+	if !f.sorting() {
+		var err error
+		each(func(k string, v interface{}) bool {
+			err = member(k, v)
+			return err == nil
+		})
+		return err
+	}
+
+	m := make(map[string]interface{})
+	each(func(k string, v interface{}) bool {
+		m[k] = v
+		return true
+	})
+	for _, k := range sortedKeys(m) {
+		if err := member(k, m[k]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
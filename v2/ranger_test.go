@@ -0,0 +1,59 @@
+package flatten
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+)
+
+// A pairs is a Ranger, keeping its pairs in order
+type pairs []Pair
+
+func (p pairs) Range(fn func(key string, v interface{}) bool) {
+	for _, pair := range p {
+		if !fn(pair.Key, pair.Value) {
+			return
+		}
+	}
+}
+
+func TestFlattenRangers(t *testing.T) {
+	var sm sync.Map
+	sm.Store("a", 1)
+	sm.Store(2, map[string]interface{}{"b": true})
+
+	nested := map[string]interface{}{
+		"sync":  &sm,
+		"pairs": pairs{{"z", "last"}, {"y", pairs{{"x", nil}}}},
+	}
+	want := map[string]interface{}{"sync.a": 1, "sync.2.b": true, "pairs.z": "last", "pairs.y.x": nil}
+
+	got, err := Flatten(nested, "", DotStyle)
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %v wanted: %v", got, want)
+	}
+
+	got, err = FlattenValue(struct{ Cache *sync.Map }{&sm}, "", DotStyle)
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+	if want := map[string]interface{}{"Cache.a": 1, "Cache.2.b": true}; !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %v wanted: %v", got, want)
+	}
+
+	ordered, err := FlattenOrdered(nested, "", DotStyle)
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+	keys := make([]string, len(ordered))
+	for i, pair := range ordered {
+		keys[i] = pair.Key
+	}
+	if !sort.StringsAreSorted(keys) {
+		t.Errorf("mismatch, got: %v wanted sorted keys", keys)
+	}
+}
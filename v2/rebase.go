@@ -0,0 +1,94 @@
+package flatten
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Rebase moves the subtree at flattened key fromPath to toPath, e.g. "db.primary" to "databases.main",
+// returning a copy of nested with the move made and nested itself unchanged.  Both paths are split by
+// style; toPath is merged in as NestInto would, adding maps as needed, and fails with a *ConflictError
+// where a value stands in the way.  A subtree moved out of a slice is removed from it, shifting later
+// elements down.
+func Rebase(nested map[string]interface{}, fromPath, toPath string, style SeparatorStyle) (map[string]interface{}, error) {
+	from, err := style.SplitKey(fromPath)
+	if err != nil {
+		return nil, err
+	}
+	to, err := style.SplitKey(toPath)
+	if err != nil {
+		return nil, err
+	}
+
+	moved := deepCopy(nested).(map[string]interface{})
+	_, subtree, ok := detach(moved, from)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", NotValidPathError, fromPath)
+	}
+
+	over := subtree
+	for i := len(to) - 1; i >= 0; i-- {
+		over = map[string]interface{}{to[i]: over}
+	}
+	if _, err := (Options{}).merge(moved, over, nil, style); err != nil {
+		return nil, err
+	}
+
+	return moved, nil
+}
+
+// detach removes the value at path under node, returning node as changed, and the value.
+func detach(node interface{}, path []string) (interface{}, interface{}, bool) {
+	if len(path) == 0 {
+		return node, nil, false
+	}
+	seg, rest := path[0], path[1:]
+
+	switch n := node.(type) {
+	case map[string]interface{}:
+		child, ok := n[seg]
+		if !ok {
+			return node, nil, false
+		}
+		if len(rest) == 0 {
+			delete(n, seg)
+			return n, child, true
+		}
+		child, v, ok := detach(child, rest)
+		n[seg] = child
+		return n, v, ok
+	case []interface{}:
+		i, err := strconv.Atoi(seg)
+		if err != nil || i < 0 || i >= len(n) {
+			return node, nil, false
+		}
+		if len(rest) == 0 {
+			v := n[i]
+			return append(n[:i], n[i+1:]...), v, true
+		}
+		child, v, ok := detach(n[i], rest)
+		n[i] = child
+		return n, v, ok
+	}
+
+	return node, nil, false
+}
+
+// deepCopy copies maps and slices, sharing other values.
+func deepCopy(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, child := range v {
+			m[k] = deepCopy(child)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(v))
+		for i, child := range v {
+			s[i] = deepCopy(child)
+		}
+		return s
+	}
+	return v
+}
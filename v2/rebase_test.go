@@ -0,0 +1,80 @@
+package flatten
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestRebase(t *testing.T) {
+	nested := func() map[string]interface{} {
+		return map[string]interface{}{
+			"db": map[string]interface{}{
+				"primary": map[string]interface{}{"host": "a", "opts": map[string]interface{}{}},
+				"ports":   []interface{}{1.0, 2.0, 3.0},
+			},
+			"apps": []interface{}{"x", map[string]interface{}{"name": "y"}},
+		}
+	}
+
+	cases := []struct {
+		from, to string
+		want     map[string]interface{}
+		err      error
+	}{
+		// 1 -- empty maps survive
+		{
+			"db.primary", "databases.main",
+			map[string]interface{}{
+				"db":        map[string]interface{}{"ports": []interface{}{1.0, 2.0, 3.0}},
+				"databases": map[string]interface{}{"main": map[string]interface{}{"host": "a", "opts": map[string]interface{}{}}},
+				"apps":      []interface{}{"x", map[string]interface{}{"name": "y"}},
+			},
+			nil,
+		},
+		// 2 -- out of a slice
+		{
+			"db.ports.1", "db.second",
+			map[string]interface{}{
+				"db": map[string]interface{}{
+					"primary": map[string]interface{}{"host": "a", "opts": map[string]interface{}{}},
+					"ports":   []interface{}{1.0, 3.0},
+					"second":  2.0,
+				},
+				"apps": []interface{}{"x", map[string]interface{}{"name": "y"}},
+			},
+			nil,
+		},
+		// 3 -- into a slice
+		{
+			"db.primary.host", "apps.1.host",
+			map[string]interface{}{
+				"db": map[string]interface{}{
+					"primary": map[string]interface{}{"opts": map[string]interface{}{}},
+					"ports":   []interface{}{1.0, 2.0, 3.0},
+				},
+				"apps": []interface{}{"x", map[string]interface{}{"name": "y", "host": "a"}},
+			},
+			nil,
+		},
+		// 4
+		{"db.missing", "x", nil, NotValidPathError},
+		// 5 -- a value in the way
+		{"db.ports", "apps.0.ports", nil, LeafParentConflictError},
+	}
+
+	for i, test := range cases {
+		original := nested()
+		got, err := Rebase(original, test.from, test.to, DotStyle)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%d: error mismatch, got: [%v], wanted: [%v]", i+1, err, test.err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%d: mismatch, got: %v wanted: %v", i+1, got, test.want)
+		}
+		if !reflect.DeepEqual(original, nested()) {
+			t.Errorf("%d: original changed: %v", i+1, original)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package flatten
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// Record path must lead through maps and slices to a slice (or a map)
+var NotValidRecordPathError = errors.New("Not a valid record path: must lead to a slice")
+
+// Meta key duplicates a record key
+var MetaConflictError = errors.New("Not a valid meta path: conflicts with a record key")
+
+// FlattenRecords explodes nested into rows, as pandas' json_normalize does: one flat row per element of
+// the slice found at recordPath, flattened with style, plus any meta fields found above it.
+//
+// Each segment of recordPath names a map key; where it leads to a slice on the way, every element is
+// followed, so "orders", "items" yields a row per item of every order.  A meta path is resolved within
+// the element it shares a prefix with, so with that record path, "customer" is read from nested itself,
+// and "orders", "id" from each order.  Meta keys are their paths joined with style; missing ones are
+// left out.  A slice element which is not a map becomes a row with the last record path segment as key.
+func FlattenRecords(nested map[string]interface{}, recordPath []string, meta [][]string, style SeparatorStyle) ([]map[string]interface{}, error) {
+	if len(recordPath) == 0 {
+		return nil, NotValidRecordPathError
+	}
+
+	var rows []map[string]interface{}
+	contexts := make([]interface{}, len(recordPath))
+
+	var descend func(level int, node interface{}) error
+	descend = func(level int, node interface{}) error {
+		contexts[level] = node
+
+		m, ok := node.(map[string]interface{})
+		if !ok {
+			return NotValidRecordPathError
+		}
+
+		child, ok := m[recordPath[level]]
+		if !ok {
+			return NotValidRecordPathError
+		}
+
+		if level+1 < len(recordPath) {
+			if list, ok := child.([]interface{}); ok {
+				for _, elem := range list {
+					if err := descend(level+1, elem); err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+			return descend(level+1, child)
+		}
+
+		switch child := child.(type) {
+		case []interface{}:
+			for _, elem := range child {
+				row, err := record(elem, recordPath, meta, contexts, style)
+				if err != nil {
+					return err
+				}
+				rows = append(rows, row)
+			}
+		case map[string]interface{}:
+			row, err := record(child, recordPath, meta, contexts, style)
+			if err != nil {
+				return err
+			}
+			rows = append(rows, row)
+		default:
+			return NotValidRecordPathError
+		}
+
+		return nil
+	}
+
+	if err := descend(0, nested); err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
+
+// record flattens one row, with its meta fields resolved among the enclosing elements in contexts.
+func record(elem interface{}, recordPath []string, meta [][]string, contexts []interface{}, style SeparatorStyle) (map[string]interface{}, error) {
+	row := make(map[string]interface{})
+
+	switch elem.(type) {
+	case map[string]interface{}, []interface{}:
+		if err := flatten(true, row, elem, "", style); err != nil {
+			return nil, err
+		}
+	default:
+		row[style.finish(enkey(true, "", recordPath[len(recordPath)-1], style))] = elem
+	}
+
+	for _, path := range meta {
+		if len(path) == 0 {
+			continue
+		}
+
+		// Resolve within the deepest element the path shares a prefix with, keeping one segment to look up.
+		level := 0
+		for level+1 < len(path) && level+1 < len(contexts) && path[level] == recordPath[level] {
+			level++
+		}
+
+		v, ok := lookup(contexts[level], path[level:])
+		if !ok {
+			continue
+		}
+
+		key := ""
+		for i, seg := range path {
+			key = enkey(i == 0, key, seg, style)
+		}
+
+		fields := map[string]interface{}{}
+		switch v.(type) {
+		case map[string]interface{}, []interface{}:
+			if err := flatten(false, fields, v, key, style); err != nil {
+				return nil, err
+			}
+		default:
+			fields[style.finish(key)] = v
+		}
+
+		for k, v := range fields {
+			if _, ok := row[k]; ok {
+				return nil, fmt.Errorf("%w: %q", MetaConflictError, k)
+			}
+			row[k] = v
+		}
+	}
+
+	return row, nil
+}
+
+// lookup follows path through maps, and slices by index, from node.
+func lookup(node interface{}, path []string) (interface{}, bool) {
+	for _, seg := range path {
+		switch n := node.(type) {
+		case map[string]interface{}:
+			v, ok := n[seg]
+			if !ok {
+				return nil, false
+			}
+			node = v
+		case []interface{}:
+			i, err := strconv.Atoi(seg)
+			if err != nil || i < 0 || i >= len(n) {
+				return nil, false
+			}
+			node = n[i]
+		default:
+			return nil, false
+		}
+	}
+
+	return node, true
+}
@@ -0,0 +1,117 @@
+package flatten
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+const ordersJSON = `{
+	"customer": { "name": "jim", "tier": "gold" },
+	"orders": [
+		{
+			"id": 1,
+			"items": [
+				{ "sku": "a", "qty": 2 },
+				{ "sku": "b", "qty": 1, "opts": { "gift": true } }
+			]
+		},
+		{
+			"id": 2,
+			"items": [ { "sku": "c", "qty": 5 } ]
+		}
+	],
+	"tags": [ "x", "y" ]
+}`
+
+func TestFlattenRecords(t *testing.T) {
+	cases := []struct {
+		path []string
+		meta [][]string
+		want []map[string]interface{}
+	}{
+		// 1
+		{
+			[]string{"orders", "items"},
+			[][]string{{"orders", "id"}, {"customer", "name"}, {"missing"}},
+			[]map[string]interface{}{
+				{"sku": "a", "qty": 2.0, "orders.id": 1.0, "customer.name": "jim"},
+				{"sku": "b", "qty": 1.0, "opts.gift": true, "orders.id": 1.0, "customer.name": "jim"},
+				{"sku": "c", "qty": 5.0, "orders.id": 2.0, "customer.name": "jim"},
+			},
+		},
+		// 2 -- meta subtree
+		{
+			[]string{"orders"},
+			[][]string{{"customer"}},
+			[]map[string]interface{}{
+				{"id": 1.0, "items.0.sku": "a", "items.0.qty": 2.0, "items.1.sku": "b", "items.1.qty": 1.0, "items.1.opts.gift": true,
+					"customer.name": "jim", "customer.tier": "gold"},
+				{"id": 2.0, "items.0.sku": "c", "items.0.qty": 5.0,
+					"customer.name": "jim", "customer.tier": "gold"},
+			},
+		},
+		// 3 -- scalar elements
+		{
+			[]string{"tags"},
+			nil,
+			[]map[string]interface{}{{"tags": "x"}, {"tags": "y"}},
+		},
+	}
+
+	var nested map[string]interface{}
+	if err := json.Unmarshal([]byte(ordersJSON), &nested); err != nil {
+		t.Fatalf("failed to unmarshal test: %v", err)
+	}
+
+	for i, test := range cases {
+		got, err := FlattenRecords(nested, test.path, test.meta, DotStyle)
+		if err != nil {
+			t.Errorf("%d: failed to flatten: %v", i+1, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%d: mismatch, got: %v wanted: %v", i+1, got, test.want)
+		}
+	}
+}
+
+func TestFlattenRecordsErrors(t *testing.T) {
+	var nested map[string]interface{}
+	if err := json.Unmarshal([]byte(ordersJSON), &nested); err != nil {
+		t.Fatalf("failed to unmarshal test: %v", err)
+	}
+
+	cases := []struct {
+		path []string
+		meta [][]string
+		err  error
+	}{
+		// 1
+		{nil, nil, NotValidRecordPathError},
+		// 2
+		{[]string{"nope"}, nil, NotValidRecordPathError},
+		// 3
+		{[]string{"customer", "name"}, nil, NotValidRecordPathError},
+	}
+
+	for i, test := range cases {
+		_, err := FlattenRecords(nested, test.path, test.meta, DotStyle)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%d: error mismatch, got: [%v], wanted: [%v]", i+1, err, test.err)
+		}
+	}
+}
+
+func TestFlattenRecordsMetaConflict(t *testing.T) {
+	nested := map[string]interface{}{
+		"sku":   "root",
+		"items": []interface{}{map[string]interface{}{"sku": "a"}},
+	}
+
+	_, err := FlattenRecords(nested, []string{"items"}, [][]string{{"sku"}}, DotStyle)
+	if !errors.Is(err, MetaConflictError) {
+		t.Errorf("error mismatch, got: [%v], wanted: [%v]", err, MetaConflictError)
+	}
+}
@@ -0,0 +1,88 @@
+package flatten
+
+// RedisHash flattens nested into the field/value arguments of an HSET, alternating and in field order,
+// with values as text.
+func RedisHash(nested map[string]interface{}, style SeparatorStyle) ([]string, error) {
+	flat, err := Flatten(nested, "", style)
+	if err != nil {
+		return nil, err
+	}
+
+	return hashArgs(flat), nil
+}
+
+// RedisHashes splits nested into several hashes, by subtree, returning the HSET field/value arguments
+// of each by hash name.  Names are key joined with style to the path of the subtree, each subtree split
+// levels down getting its own hash; a leaf less than split levels down lands in the hash of its parent.
+// With split 0, this is a single hash named key, as RedisHash.
+func RedisHashes(key string, nested map[string]interface{}, split int, style SeparatorStyle) (map[string][]string, error) {
+	hashes := make(map[string]map[string]interface{})
+
+	var descend func(name string, depth int, node interface{}) error
+	descend = func(name string, depth int, node interface{}) error {
+		if depth >= split {
+			flat := hashes[name]
+			if flat == nil {
+				flat = make(map[string]interface{})
+				hashes[name] = flat
+			}
+			return flatten(true, flat, node, "", style)
+		}
+
+		child := func(join func(top bool, prefix string) string, v interface{}) error {
+			switch v.(type) {
+			case map[string]interface{}, []interface{}:
+				return descend(join(false, name), depth+1, v)
+			}
+			if hashes[name] == nil {
+				hashes[name] = make(map[string]interface{})
+			}
+			hashes[name][style.finish(join(true, ""))] = v
+			return nil
+		}
+
+		switch node := node.(type) {
+		case map[string]interface{}:
+			for k, v := range node {
+				k := k
+				join := func(top bool, prefix string) string { return enkey(top, prefix, k, style) }
+				if err := child(join, v); err != nil {
+					return err
+				}
+			}
+		case []interface{}:
+			for i, v := range node {
+				i := i
+				join := func(top bool, prefix string) string { return enindex(top, prefix, i, style) }
+				if err := child(join, v); err != nil {
+					return err
+				}
+			}
+		default:
+			return NotValidInputError
+		}
+
+		return nil
+	}
+
+	if err := descend(key, 0, nested); err != nil {
+		return nil, err
+	}
+
+	args := make(map[string][]string, len(hashes))
+	for name, flat := range hashes {
+		if len(flat) > 0 {
+			args[name] = hashArgs(flat)
+		}
+	}
+
+	return args, nil
+}
+
+func hashArgs(flat map[string]interface{}) []string {
+	args := make([]string, 0, 2*len(flat))
+	for _, k := range sortedKeys(flat) {
+		args = append(args, k, formatValue(flat[k]))
+	}
+	return args
+}
@@ -0,0 +1,80 @@
+package flatten
+
+import (
+	"reflect"
+	"testing"
+)
+
+var redisDoc = map[string]interface{}{
+	"name": "jim",
+	"prefs": map[string]interface{}{
+		"theme": "dark",
+		"alerts": map[string]interface{}{
+			"email": true,
+		},
+	},
+	"roles": []interface{}{"admin", "dev"},
+}
+
+func TestRedisHash(t *testing.T) {
+	got, err := RedisHash(redisDoc, DotStyle)
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+
+	want := []string{
+		"name", "jim",
+		"prefs.alerts.email", "true",
+		"prefs.theme", "dark",
+		"roles.0", "admin",
+		"roles.1", "dev",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %q wanted: %q", got, want)
+	}
+}
+
+func TestRedisHashes(t *testing.T) {
+	cases := []struct {
+		split int
+		want  map[string][]string
+	}{
+		// 1
+		{
+			0,
+			map[string][]string{
+				"user:1": {"name", "jim", "prefs.alerts.email", "true", "prefs.theme", "dark", "roles.0", "admin", "roles.1", "dev"},
+			},
+		},
+		// 2
+		{
+			1,
+			map[string][]string{
+				"user:1":       {"name", "jim"},
+				"user:1.prefs": {"alerts.email", "true", "theme", "dark"},
+				"user:1.roles": {"0", "admin", "1", "dev"},
+			},
+		},
+		// 3 -- deeper than some leaves
+		{
+			2,
+			map[string][]string{
+				"user:1":              {"name", "jim"},
+				"user:1.prefs":        {"theme", "dark"},
+				"user:1.prefs.alerts": {"email", "true"},
+				"user:1.roles":        {"0", "admin", "1", "dev"},
+			},
+		},
+	}
+
+	for i, test := range cases {
+		got, err := RedisHashes("user:1", redisDoc, test.split, DotStyle)
+		if err != nil {
+			t.Errorf("%d: failed to flatten: %v", i+1, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%d: mismatch, got: %q wanted: %q", i+1, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,407 @@
+package flatten
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// unwrap converts maps with string keys, slices and arrays, of any type, to the map[string]interface{}
+// and []interface{} which are flattened, e.g. a `type Labels map[string]string`.  Byte slices are leaves,
+// like other values, and are returned as they are; byte arrays become byte slices.
+//
+// A json.Marshaler is replaced by its marshaled form, decoded, so types with their own JSON
+// representation flatten as they appear in JSON output; see decodeMarshaled for its numbers.  Otherwise,
+// an encoding.TextMarshaler, e.g. net.IP, is replaced by its text, as a string leaf.
+func (o Options) unwrap(v interface{}) (interface{}, error) {
+	switch tv := v.(type) {
+	case nil, string, float64, bool, map[string]interface{}, []interface{}, []byte, OrderedMap, Ranger, anyRanger:
+		return v, nil
+	case json.Marshaler:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		return o.decodeMarshaled(b)
+	case encoding.TextMarshaler:
+		b, err := tv.MarshalText()
+		if err != nil {
+			return nil, err
+		}
+		return string(b), nil
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return v, nil
+		}
+		m := make(map[string]interface{}, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			m[iter.Key().String()] = iter.Value().Interface()
+		}
+		return m, nil
+	case reflect.Slice, reflect.Array:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			if rv.Kind() == reflect.Slice {
+				return v, nil
+			}
+			b := make([]byte, rv.Len())
+			reflect.Copy(reflect.ValueOf(b), rv)
+			return b, nil
+		}
+		s := make([]interface{}, rv.Len())
+		for i := range s {
+			s[i] = rv.Index(i).Interface()
+		}
+		return s, nil
+	}
+
+	return v, nil
+}
+
+// decodeMarshaled decodes the JSON of a json.Marshaler as decodeJSON does, except that without UseNumber
+// only the numbers a float64 holds exactly become float64s.  Integers beyond 2^53, e.g. from a *big.Int,
+// stay json.Number, keeping their digits.
+func (o Options) decodeMarshaled(b []byte) (interface{}, error) {
+	v, err := Options{UseNumber: true}.decodeJSON(b)
+	if err != nil || o.UseNumber {
+		return v, err
+	}
+	return exactNumbers(v), nil
+}
+
+// exactNumbers replaces the json.Numbers in v, a decoded JSON value, with float64s, except integers too
+// large for a float64 to hold exactly.
+func exactNumbers(v interface{}) interface{} {
+	switch tv := v.(type) {
+	case map[string]interface{}:
+		for k, child := range tv {
+			tv[k] = exactNumbers(child)
+		}
+	case []interface{}:
+		for i, child := range tv {
+			tv[i] = exactNumbers(child)
+		}
+	case json.Number:
+		const exact = 1 << 53
+		if i, err := tv.Int64(); err == nil {
+			if i < -exact || i > exact {
+				return tv
+			}
+			return float64(i)
+		}
+		if !strings.ContainsAny(string(tv), ".eE") {
+			return tv // an integer beyond int64
+		}
+		if f, err := tv.Float64(); err == nil {
+			return f
+		}
+		return tv
+	}
+	return v
+}
+
+// FlattenValue generates a flat map from any Go value, reflecting over structs, maps, slices, arrays and
+// pointers, so config structs need not go through JSON to be flattened.  Struct keys are those
+// json.Marshal would write: the json tag names of exported fields, or else their own names, with
+// omitempty, omitzero, "-" and the string option honored, and the fields of embedded structs promoted.
+// Structs left without fields, as none are exported or all are omitted, are empty maps.  Types with their
+// own JSON or text representation, e.g. time.Time or net.IP, are treated as Flatten treats them.  Nil
+// pointers, maps and slices are nil values.  The value must reflect to a map or slice.
+func FlattenValue(v interface{}, prefix string, style SeparatorStyle) (map[string]interface{}, error) {
+	return Options{}.flattenValue("FlattenValue", v, prefix, style)
+}
+
+// FlattenValue generates a flat map from any Go value, as the package-level FlattenValue does, under the
+// options.  Fields are named by the tag of TagName, e.g. a `mapstructure:"pool_size"` tag, with its
+// options read as for json.  Nil pointers and interfaces are kept, left out or made empty strings, as
+// Nils says.
+func (o Options) FlattenValue(v interface{}, prefix string, style SeparatorStyle) (map[string]interface{}, error) {
+	return o.flattenValue("Options.FlattenValue", v, prefix, style)
+}
+
+func (o Options) flattenValue(op string, v interface{}, prefix string, style SeparatorStyle) (flatmap map[string]interface{}, err error) {
+	done := observe(op, 0)
+	flatmap = make(map[string]interface{})
+	defer func() { done(len(flatmap), err) }()
+
+	f := flattener{style: o.styled(style), opts: o, structs: true}
+	f.enter(v) // as the top, against pointers back to it
+	nested, err := f.unwrap(v)
+	if err != nil {
+		return nil, err
+	}
+	switch nested.(type) {
+	case map[string]interface{}, []interface{}, OrderedMap, Ranger, anyRanger:
+	default:
+		return nil, NotValidInputError
+	}
+
+	if err := f.flatten(true, flatmap, nested, prefix, 1); err != nil {
+		return nil, err
+	}
+
+	return flatmap, nil
+}
+
+// unwrap is Options.unwrap, first replacing a Flattenable, or a value of a type with a
+// registered flattener, by what it gives.  It also decodes json.RawMessage in place, converts maps keyed
+// by interface{}, with the keys which are not strings formatted, presents times per Options.Times, and,
+// if the flattener reflects over structs, converts structs to maps of their fields and follows pointers.
+func (f *flattener) unwrap(v interface{}) (interface{}, error) {
+	converted, ok, err := custom(v)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		v = converted
+	}
+
+	switch t := v.(type) {
+	case time.Time:
+		if r, ok := f.opts.Times.render(t); ok {
+			return r, nil
+		}
+	case *time.Time:
+		if t != nil {
+			if r, ok := f.opts.Times.render(*t); ok {
+				return r, nil
+			}
+		}
+	}
+	if raw, ok := v.(json.RawMessage); ok {
+		if len(raw) == 0 {
+			return nil, nil // as json.Marshal has it
+		}
+		return f.opts.decodeJSON(raw)
+	}
+	if m, ok := v.(map[interface{}]interface{}); ok {
+		converted := make(map[string]interface{}, len(m))
+		for k, child := range m {
+			s, ok := k.(string)
+			if !ok {
+				s = f.opts.formatKey(k)
+			}
+			converted[s] = child
+		}
+		return converted, nil
+	}
+
+	if !f.structs || v == nil {
+		return f.opts.unwrap(v)
+	}
+
+	rv := reflect.ValueOf(v)
+	if (rv.Kind() == reflect.Map || rv.Kind() == reflect.Slice) && rv.IsNil() {
+		return nil, nil // null, as in JSON, rather than empty
+	}
+
+	v, err = f.opts.unwrap(v)
+	if err != nil {
+		return v, err
+	}
+	switch v.(type) {
+	case Ranger, anyRanger:
+		return v, nil // e.g. a *sync.Map, visited by its pairs rather than its fields
+	}
+
+	rv = reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Ptr:
+		if rv.IsNil() {
+			return nil, nil
+		}
+		return f.unwrap(rv.Elem().Interface())
+	case reflect.Struct:
+		if pt := reflect.PtrTo(rv.Type()); pt.Implements(marshalerType) || pt.Implements(textMarshalerType) {
+			p := reflect.New(rv.Type())
+			p.Elem().Set(rv)
+			return f.opts.unwrap(p.Interface())
+		}
+		return structFields(rv, f.opts.tagName()), nil // as json.Marshal writes {}, even with no fields
+	}
+
+	return v, nil
+}
+
+// isNilRef reports whether v is a nil interface, or a nil pointer in one.
+func isNilRef(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Ptr && rv.IsNil()
+}
+
+var (
+	marshalerType     = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// A structField is a field found in a struct, or promoted from an embedded one.
+type structField struct {
+	name   string
+	depth  int  // Levels of embedding
+	tagged bool // Named by a tag
+	value  interface{}
+}
+
+// structFields maps the exported fields of a struct by their names, as encoding/json would, read from
+// the tag given: fields tagged "-" are skipped, and with omitempty or omitzero, so are empty or zero
+// values; the string option renders numbers, booleans and strings as JSON text.  Embedded structs
+// without a tag name have their fields promoted; of fields with one name, the shallowest wins, then a
+// tagged one, and if that leaves several, none does.
+func structFields(rv reflect.Value, tag string) map[string]interface{} {
+	var fields []structField
+	collectFields(&fields, rv, tag, 0)
+
+	byName := make(map[string][]structField)
+	for _, field := range fields {
+		byName[field.name] = append(byName[field.name], field)
+	}
+
+	m := make(map[string]interface{}, len(byName))
+	for name, candidates := range byName {
+		if field, ok := dominant(candidates); ok {
+			m[name] = field.value
+		}
+	}
+	return m
+}
+
+// dominant picks among fields of one name the shallowest, then the tagged one, if that leaves one.
+func dominant(fields []structField) (structField, bool) {
+	depth := fields[0].depth
+	for _, field := range fields {
+		if field.depth < depth {
+			depth = field.depth
+		}
+	}
+
+	var shallow, tagged []structField
+	for _, field := range fields {
+		if field.depth == depth {
+			shallow = append(shallow, field)
+			if field.tagged {
+				tagged = append(tagged, field)
+			}
+		}
+	}
+
+	switch {
+	case len(shallow) == 1:
+		return shallow[0], true
+	case len(tagged) == 1:
+		return tagged[0], true
+	}
+	return structField{}, false
+}
+
+// collectFields appends the fields of a struct, and of the structs it embeds, at depth.
+func collectFields(fields *[]structField, rv reflect.Value, tag string, depth int) {
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fv := rv.Field(i)
+
+		name, opts := field.Name, ""
+		tagged := false
+		if tv, ok := field.Tag.Lookup(tag); ok {
+			if tv == "-" {
+				continue
+			}
+			tagName := tv
+			if comma := strings.IndexByte(tv, ','); comma >= 0 {
+				tagName, opts = tv[:comma], tv[comma:]
+			}
+			if tagName != "" {
+				name, tagged = tagName, true
+			}
+		}
+
+		if field.Anonymous && !tagged {
+			ev := fv
+			if ev.Kind() == reflect.Ptr {
+				if ev.IsNil() {
+					continue
+				}
+				ev = ev.Elem()
+			}
+			if ev.Kind() == reflect.Struct {
+				collectFields(fields, ev, tag, depth+1)
+				continue
+			}
+		}
+		if field.PkgPath != "" || !fv.CanInterface() {
+			continue // unexported, or reached through an unexported embedded struct
+		}
+
+		if (hasOption(opts, "omitempty") && isEmptyValue(fv)) || (hasOption(opts, "omitzero") && isZeroValue(fv)) {
+			continue
+		}
+
+		v := fv.Interface()
+		if hasOption(opts, "string") {
+			v = quoted(fv)
+		}
+		*fields = append(*fields, structField{name: name, depth: depth, tagged: tagged, value: v})
+	}
+}
+
+func hasOption(opts, option string) bool {
+	for _, o := range strings.Split(opts, ",") {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
+
+// isEmptyValue reports whether v is empty, to omitempty: false, 0, a nil pointer or interface, or an
+// empty string, map, slice or array.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Interface, reflect.Ptr:
+		return v.IsZero()
+	}
+	return false
+}
+
+var zeroerType = reflect.TypeOf((*interface{ IsZero() bool })(nil)).Elem()
+
+// isZeroValue reports whether v is zero, to omitzero: by its IsZero method, if it has one, as time.Time
+// does.
+func isZeroValue(v reflect.Value) bool {
+	if v.Type().Implements(zeroerType) {
+		if v.Kind() == reflect.Ptr && v.IsNil() {
+			return true
+		}
+		return v.Interface().(interface{ IsZero() bool }).IsZero()
+	}
+	return v.IsZero()
+}
+
+// quoted renders a field with the string option: numbers and booleans as their text, strings as JSON
+// strings, and others as they are, as encoding/json ignores the option for them.
+func quoted(v reflect.Value) interface{} {
+	switch v.Kind() {
+	case reflect.String:
+		b, _ := json.Marshal(v.String())
+		return string(b)
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		b, _ := json.Marshal(v.Interface())
+		return string(b)
+	}
+	return v.Interface()
+}
@@ -0,0 +1,553 @@
+package flatten
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type labels map[string]string
+
+type item struct{ Name string }
+
+type items []item
+
+type weights []float64
+
+func TestFlattenNamedTypes(t *testing.T) {
+	cases := []struct {
+		nested map[string]interface{}
+		want   map[string]interface{}
+	}{
+		// 1
+		{
+			map[string]interface{}{"labels": labels{"app": "web", "tier": "front"}},
+			map[string]interface{}{"labels.app": "web", "labels.tier": "front"},
+		},
+		// 2
+		{
+			map[string]interface{}{"w": weights{0.5, 1.5}, "n": []int{7}},
+			map[string]interface{}{"w.0": 0.5, "w.1": 1.5, "n.0": 7},
+		},
+		// 3 -- elements which are not maps or slices stay whole
+		{
+			map[string]interface{}{"items": items{{"a"}}},
+			map[string]interface{}{"items.0": item{"a"}},
+		},
+		// 4
+		{
+			map[string]interface{}{"m": map[string][]string{"k": {"x", "y"}}},
+			map[string]interface{}{"m.k.0": "x", "m.k.1": "y"},
+		},
+		// 5 -- byte slices, and maps without string keys, are values
+		{
+			map[string]interface{}{"b": []byte("hi"), "i": map[int]string{1: "one"}},
+			map[string]interface{}{"b": []byte("hi"), "i": map[int]string{1: "one"}},
+		},
+		// 6
+		{
+			map[string]interface{}{"v": [2]float64{1, 2}, "id": [4]byte{'a', 'b', 'c', 'd'}},
+			map[string]interface{}{"v.0": 1.0, "v.1": 2.0, "id": []byte("abcd")},
+		},
+		// 7
+		{
+			map[string]interface{}{"grid": [2][2]int{{1, 2}, {3, 4}}},
+			map[string]interface{}{"grid.0.0": 1, "grid.0.1": 2, "grid.1.0": 3, "grid.1.1": 4},
+		},
+	}
+
+	for i, test := range cases {
+		got, err := Flatten(test.nested, "", DotStyle)
+		if err != nil {
+			t.Errorf("%d: failed to flatten: %v", i+1, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%d: mismatch, got: %v wanted: %v", i+1, got, test.want)
+		}
+	}
+}
+
+type point struct{ x, y int }
+
+func (p point) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]int{"x": p.x, "y": p.y})
+}
+
+type level int
+
+func (l level) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]string{"debug", "info"}[l])
+}
+
+type broken struct{}
+
+var brokenError = errors.New("broken")
+
+func (broken) MarshalJSON() ([]byte, error) {
+	return nil, brokenError
+}
+
+func TestFlattenMarshalers(t *testing.T) {
+	got, err := Flatten(map[string]interface{}{
+		"at":    point{1, 2},
+		"level": level(1),
+		"raw":   json.RawMessage(`[true, {"k": null}]`),
+	}, "", DotStyle)
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+	want := map[string]interface{}{
+		"at.x":    1.0,
+		"at.y":    2.0,
+		"level":   "info",
+		"raw.0":   true,
+		"raw.1.k": nil,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %v wanted: %v", got, want)
+	}
+
+	_, err = Flatten(map[string]interface{}{"b": broken{}}, "", DotStyle)
+	if !errors.Is(err, brokenError) {
+		t.Errorf("error mismatch, got: [%v], wanted: [%v]", err, brokenError)
+	}
+}
+
+func TestFlattenMarshalerNumbers(t *testing.T) {
+	large, _ := new(big.Int).SetString("9007199254740993", 10) // 2^53 + 1
+	nested := map[string]interface{}{"large": large, "small": big.NewInt(42)}
+
+	got, err := Flatten(nested, "", DotStyle)
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+	want := map[string]interface{}{"large": json.Number("9007199254740993"), "small": 42.0}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %v wanted: %v", got, want)
+	}
+
+	got, err = FlattenValue(nested, "", DotStyle)
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("value mismatch, got: %v wanted: %v", got, want)
+	}
+
+	got, err = Options{UseNumber: true}.FlattenValue(nested, "", DotStyle)
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+	want = map[string]interface{}{"large": json.Number("9007199254740993"), "small": json.Number("42")}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("UseNumber mismatch, got: %v wanted: %v", got, want)
+	}
+}
+
+// A version has exported fields, but its own text form
+type version struct{ Major, Minor int }
+
+func (v *version) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("v%d.%d", v.Major, v.Minor)), nil
+}
+
+type release struct {
+	Version version
+	Host    net.IP
+	Next    *version
+}
+
+func TestFlattenTextMarshalers(t *testing.T) {
+	got, err := Flatten(map[string]interface{}{
+		"host":    net.ParseIP("10.0.0.1"),
+		"version": &version{1, 2},
+		"at":      point{1, 2}, // json.Marshaler first
+	}, "", DotStyle)
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+	want := map[string]interface{}{"host": "10.0.0.1", "version": "v1.2", "at.x": 1.0, "at.y": 2.0}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %v wanted: %v", got, want)
+	}
+
+	got, err = FlattenValue(release{Version: version{2, 0}, Host: net.ParseIP("::1"), Next: &version{2, 1}}, "", DotStyle)
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+	want = map[string]interface{}{"Version": "v2.0", "Host": "::1", "Next": "v2.1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %v wanted: %v", got, want)
+	}
+}
+
+type Base struct {
+	ID   int
+	Name string
+}
+
+type server struct {
+	Base
+	Name    string // shadows Base.Name
+	Ports   []int
+	Limits  map[string]float64
+	TLS     *tlsConfig
+	Proxy   *tlsConfig
+	Started time.Time
+	Size    big.Int
+	hidden  string
+}
+
+type tlsConfig struct {
+	Cert string
+}
+
+type opaque struct{ n int }
+
+func TestFlattenValue(t *testing.T) {
+	at := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	srv := &server{
+		Base:    Base{ID: 1, Name: "base"},
+		Name:    "api",
+		Ports:   []int{80, 443},
+		Limits:  map[string]float64{"cpu": 0.5},
+		TLS:     &tlsConfig{Cert: "c.pem"},
+		Started: at,
+		Size:    *big.NewInt(42),
+		hidden:  "x",
+	}
+
+	cases := []struct {
+		value interface{}
+		want  map[string]interface{}
+		err   error
+	}{
+		// 1
+		{
+			srv,
+			map[string]interface{}{
+				"ID":         1,
+				"Name":       "api",
+				"Ports.0":    80,
+				"Ports.1":    443,
+				"Limits.cpu": 0.5,
+				"TLS.Cert":   "c.pem",
+				"Proxy":      nil,
+				"Started":    "2024-05-01T10:00:00Z",
+				"Size":       42.0,
+			},
+			nil,
+		},
+		// 2
+		{
+			[]interface{}{tlsConfig{"a"}, &opaque{1}},
+			map[string]interface{}{"0.Cert": "a"},
+			nil,
+		},
+		// 3
+		{
+			map[string]*tlsConfig{"x": {"b"}, "y": nil},
+			map[string]interface{}{"x.Cert": "b", "y": nil},
+			nil,
+		},
+		// 4
+		{"scalar", nil, NotValidInputError},
+		// 5
+		{(*server)(nil), nil, NotValidInputError},
+	}
+
+	for i, test := range cases {
+		got, err := FlattenValue(test.value, "", DotStyle)
+		if err != test.err {
+			t.Errorf("%d: error mismatch, got: [%v], wanted: [%v]", i+1, err, test.err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%d: mismatch, got: %v wanted: %v", i+1, got, test.want)
+		}
+	}
+}
+
+type inner struct {
+	A string `json:",omitempty"`
+}
+
+type outer struct {
+	In inner
+}
+
+func TestFlattenValueEmptyStructs(t *testing.T) {
+	cases := []struct {
+		value interface{}
+		key   string
+	}{
+		// 1
+		{outer{In: inner{}}, "In"},
+		// 2
+		{struct{ X struct{} }{}, "X"},
+		// 3
+		{struct{ X opaque }{}, "X"},
+	}
+
+	for i, test := range cases {
+		got, err := FlattenValue(test.value, "", DotStyle)
+		if err != nil {
+			t.Errorf("%d: failed to flatten: %v", i+1, err)
+			continue
+		}
+		if want := (map[string]interface{}{}); !reflect.DeepEqual(got, want) {
+			t.Errorf("%d: mismatch, got: %v wanted: %v", i+1, got, want)
+		}
+
+		got, err = Options{KeepEmpty: true}.FlattenValue(test.value, "", DotStyle)
+		if err != nil {
+			t.Errorf("%d: failed to flatten keeping empties: %v", i+1, err)
+			continue
+		}
+		if want := (map[string]interface{}{test.key: map[string]interface{}{}}); !reflect.DeepEqual(got, want) {
+			t.Errorf("%d: KeepEmpty mismatch, got: %v wanted: %v", i+1, got, want)
+		}
+	}
+}
+
+type Audit struct {
+	By string `json:"by"`
+	At int    `json:"at,omitempty"`
+}
+
+type Left struct{ Dup, Tie int }
+
+type Right struct {
+	Dup int
+	Tie int `json:"Tie"`
+}
+
+type tagged struct {
+	ID      int               `json:"id,string"`
+	Name    string            `json:"name"`
+	Note    string            `json:"note,omitempty"`
+	Tags    []string          `json:"tags,omitempty"`
+	Secret  string            `json:"-"`
+	Dash    string            `json:"-,"`
+	Quoted  string            `json:",string"`
+	Ratio   float64           `json:"ratio,omitempty"`
+	Started time.Time         `json:"started,omitzero"`
+	Labels  map[string]string `json:"labels"`
+	Audit   `json:"audit"`
+	Left
+	Right
+}
+
+func TestFlattenValueTags(t *testing.T) {
+	values := []tagged{
+		// 1
+		{ID: 7, Name: "a", Secret: "s", Dash: "d", Quoted: "q", Labels: map[string]string{"k": "v"}, Audit: Audit{By: "me"}, Left: Left{1, 2}, Right: Right{3, 4}},
+		// 2
+		{Note: "n", Tags: []string{"x"}, Ratio: 0.5, Started: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), Audit: Audit{At: 9}},
+	}
+
+	for i, v := range values {
+		got, err := FlattenValue(v, "", DotStyle)
+		if err != nil {
+			t.Errorf("%d: failed to flatten: %v", i+1, err)
+			continue
+		}
+
+		// As through JSON
+		b, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("%d: failed to marshal: %v", i+1, err)
+		}
+		var nested map[string]interface{}
+		if err := json.Unmarshal(b, &nested); err != nil {
+			t.Fatalf("%d: failed to unmarshal: %v", i+1, err)
+		}
+		want, err := Flatten(nested, "", DotStyle)
+		if err != nil {
+			t.Fatalf("%d: failed to flatten JSON: %v", i+1, err)
+		}
+
+		if !reflect.DeepEqual(normalizeNumbers(got), want) {
+			t.Errorf("%d: mismatch, got: %v wanted: %v", i+1, got, want)
+		}
+	}
+}
+
+// normalizeNumbers converts ints to float64, as JSON decodes them.
+func normalizeNumbers(flat map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(flat))
+	for k, v := range flat {
+		if n, ok := v.(int); ok {
+			v = float64(n)
+		}
+		out[k] = v
+	}
+	return out
+}
+
+type viperConfig struct {
+	PoolSize int      `mapstructure:"pool_size" json:"poolSize"`
+	Hosts    []string `mapstructure:"hosts,omitempty"`
+	Debug    bool     `mapstructure:"-"`
+	Plain    string
+}
+
+func TestFlattenValueTagName(t *testing.T) {
+	cfg := viperConfig{PoolSize: 10, Debug: true, Plain: "p"}
+
+	cases := []struct {
+		opts Options
+		want map[string]interface{}
+	}{
+		// 1
+		{Options{TagName: "mapstructure"}, map[string]interface{}{"pool_size": 10, "Plain": "p"}},
+		// 2
+		{Options{}, map[string]interface{}{"poolSize": 10, "Hosts": nil, "Debug": true, "Plain": "p"}},
+	}
+
+	for i, test := range cases {
+		got, err := test.opts.FlattenValue(cfg, "", DotStyle)
+		if err != nil {
+			t.Errorf("%d: failed to flatten: %v", i+1, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%d: mismatch, got: %v wanted: %v", i+1, got, test.want)
+		}
+	}
+}
+
+func TestFlattenInterfaceKeys(t *testing.T) {
+	// As yaml.v2 decodes
+	nested := map[string]interface{}{
+		"codes": map[interface{}]interface{}{
+			200: "ok",
+			"x": map[interface{}]interface{}{true: []interface{}{map[interface{}]interface{}{"k": 1}}},
+			nil: "none",
+			1.5: "ratio",
+		},
+	}
+
+	cases := []struct {
+		opts Options
+		want map[string]interface{}
+	}{
+		// 1
+		{
+			Options{},
+			map[string]interface{}{
+				"codes.200":        "ok",
+				"codes.x.true.0.k": 1,
+				"codes.null":       "none",
+				"codes.1.5":        "ratio",
+			},
+		},
+		// 2
+		{
+			Options{KeyFormatter: func(k interface{}) string { return fmt.Sprintf("<%v>", k) }},
+			map[string]interface{}{
+				"codes.<200>":        "ok",
+				"codes.x.<true>.0.k": 1,
+				"codes.<<nil>>":      "none",
+				"codes.<1.5>":        "ratio",
+			},
+		},
+	}
+
+	for i, test := range cases {
+		got, err := test.opts.Flatten(nested, "", DotStyle)
+		if err != nil {
+			t.Errorf("%d: failed to flatten: %v", i+1, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%d: mismatch, got: %v wanted: %v", i+1, got, test.want)
+		}
+	}
+}
+
+func TestFlattenRawMessage(t *testing.T) {
+	cases := []struct {
+		opts   Options
+		nested map[string]interface{}
+		want   map[string]interface{}
+		err    bool
+	}{
+		// 1
+		{
+			Options{},
+			map[string]interface{}{"event": json.RawMessage(`{"id": 12345678901234567, "tags": ["a"]}`), "empty": json.RawMessage(nil)},
+			map[string]interface{}{"event.id": 12345678901234568.0, "event.tags.0": "a", "empty": nil},
+			false,
+		},
+		// 2
+		{
+			Options{UseNumber: true},
+			map[string]interface{}{"event": json.RawMessage(`{"id": 12345678901234567}`)},
+			map[string]interface{}{"event.id": json.Number("12345678901234567")},
+			false,
+		},
+		// 3
+		{
+			Options{},
+			map[string]interface{}{"event": json.RawMessage(`{"id": `)},
+			nil,
+			true,
+		},
+	}
+
+	for i, test := range cases {
+		got, err := test.opts.Flatten(test.nested, "", DotStyle)
+		if (err != nil) != test.err {
+			t.Errorf("%d: error mismatch, got: [%v]", i+1, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%d: mismatch, got: %v wanted: %v", i+1, got, test.want)
+		}
+	}
+}
+
+type nilable struct {
+	TLS   *tlsConfig
+	Err   error
+	Any   interface{}
+	Hosts []string
+	Name  string
+}
+
+func TestFlattenValueNils(t *testing.T) {
+	v := map[string]interface{}{"s": nilable{Name: "a"}, "p": (*tlsConfig)(nil)}
+
+	cases := []struct {
+		policy NilPolicy
+		want   map[string]interface{}
+	}{
+		// 1
+		{NilAsNull, map[string]interface{}{"s.TLS": nil, "s.Err": nil, "s.Any": nil, "s.Hosts": nil, "s.Name": "a", "p": nil}},
+		// 2 -- nil slices are not pointers, and stay
+		{SkipNil, map[string]interface{}{"s.Hosts": nil, "s.Name": "a"}},
+		// 3
+		{NilAsEmpty, map[string]interface{}{"s.TLS": "", "s.Err": "", "s.Any": "", "s.Hosts": nil, "s.Name": "a", "p": ""}},
+	}
+
+	for i, test := range cases {
+		got, err := Options{Nils: test.policy}.FlattenValue(v, "", DotStyle)
+		if err != nil {
+			t.Errorf("%d: failed to flatten: %v", i+1, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%d: mismatch, got: %v wanted: %v", i+1, got, test.want)
+		}
+	}
+}
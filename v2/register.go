@@ -0,0 +1,59 @@
+package flatten
+
+import (
+	"reflect"
+	"sync"
+)
+
+// A Flattenable controls how it is flattened: in its place, flattening takes the value it returns,
+// e.g. a string for a decimal, or a map of its parts for a composite type, and flattens that as it
+// would any other.
+type Flattenable interface {
+	Flattened() (interface{}, error)
+}
+
+var flatteners sync.Map // reflect.Type to func(interface{}) (interface{}, error)
+
+// RegisterFlattener has values of type t flattened as fn returns them, as a Flattenable's are, for
+// types from other packages, e.g.
+//
+//	flatten.RegisterFlattener(reflect.TypeOf(decimal.Decimal{}), func(v interface{}) (interface{}, error) {
+//		return v.(decimal.Decimal).String(), nil
+//	})
+//
+// A registered function takes precedence over a Flattenable, and over the MarshalJSON and MarshalText
+// methods of t.  A nil fn removes the registration.  The registry is shared by all goroutines, and is
+// best filled in init functions.
+func RegisterFlattener(t reflect.Type, fn func(v interface{}) (interface{}, error)) {
+	if fn == nil {
+		flatteners.Delete(t)
+		return
+	}
+	flatteners.Store(t, fn)
+}
+
+// custom returns the value v is to be flattened as, by a registered function or its Flattened method,
+// or false if it has neither.  Nil pointers have neither.
+func custom(v interface{}) (interface{}, bool, error) {
+	if v == nil {
+		return nil, false, nil
+	}
+	fn, registered := flatteners.Load(reflect.TypeOf(v))
+	if !registered {
+		if _, ok := v.(Flattenable); !ok {
+			return nil, false, nil
+		}
+	}
+	if isNilRef(v) {
+		return nil, false, nil
+	}
+
+	var converted interface{}
+	var err error
+	if registered {
+		converted, err = fn.(func(interface{}) (interface{}, error))(v)
+	} else {
+		converted, err = v.(Flattenable).Flattened()
+	}
+	return converted, true, err
+}
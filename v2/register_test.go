@@ -0,0 +1,57 @@
+package flatten
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// A money flattens to its parts
+type money struct{ cents int64 }
+
+func (m money) Flattened() (interface{}, error) {
+	return map[string]interface{}{"units": m.cents / 100, "cents": m.cents % 100}, nil
+}
+
+type seconds time.Duration
+
+var negativeError = errors.New("negative")
+
+func TestRegisterFlattener(t *testing.T) {
+	RegisterFlattener(reflect.TypeOf(seconds(0)), func(v interface{}) (interface{}, error) {
+		if v.(seconds) < 0 {
+			return nil, negativeError
+		}
+		return time.Duration(v.(seconds)).Seconds(), nil
+	})
+	defer RegisterFlattener(reflect.TypeOf(seconds(0)), nil)
+
+	nested := map[string]interface{}{
+		"price":   money{1250},
+		"timeout": seconds(1500 * time.Millisecond),
+		"none":    (*money)(nil),
+	}
+	got, err := Flatten(nested, "", DotStyle)
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+	want := map[string]interface{}{"price.units": int64(12), "price.cents": int64(50), "timeout": 1.5, "none": (*money)(nil)}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %v wanted: %v", got, want)
+	}
+
+	got, err = FlattenValue(struct{ Price *money }{&money{5}}, "", DotStyle)
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+	want = map[string]interface{}{"Price.units": int64(0), "Price.cents": int64(5)}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %v wanted: %v", got, want)
+	}
+
+	_, err = Flatten(map[string]interface{}{"t": seconds(-1)}, "", DotStyle)
+	if !errors.Is(err, negativeError) {
+		t.Errorf("error mismatch, got: [%v], wanted: [%v]", err, negativeError)
+	}
+}
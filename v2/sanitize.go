@@ -0,0 +1,185 @@
+package flatten
+
+import (
+	"strings"
+	"unicode"
+)
+
+// A KeySanitizer rewrites map key segments to suit the naming rules of a target system.  Unlike an
+// Escaper, it need not be reversible.
+type KeySanitizer interface {
+	SanitizeKey(segment string) string
+}
+
+// KeySanitizerFunc adapts an ordinary function to a KeySanitizer.
+type KeySanitizerFunc func(segment string) string
+
+func (f KeySanitizerFunc) SanitizeKey(segment string) string {
+	return f(segment)
+}
+
+// ChainSanitizers returns a KeySanitizer applying each of sanitizers in turn, so separate concerns (say
+// case, charset, then length) can be layered on any style.  Nil sanitizers are skipped.
+func ChainSanitizers(sanitizers ...KeySanitizer) KeySanitizer {
+	return sanitizerChain(sanitizers)
+}
+
+type sanitizerChain []KeySanitizer
+
+func (c sanitizerChain) SanitizeKey(segment string) string {
+	for _, s := range c {
+		if s != nil {
+			segment = s.SanitizeKey(segment)
+		}
+	}
+	return segment
+}
+
+// A CharsetSanitizer rewrites key segments, or whole keys, to the characters a target system allows.
+// Each other character becomes the Replacement, or is dropped if it is empty, and a result starting
+// with a digit gets the DigitPrefix, for systems whose names cannot.
+type CharsetSanitizer struct {
+	Allowed     func(r rune) bool // Whether a character may stay
+	Replacement string            // What each character not allowed becomes, e.g. "_"
+	DigitPrefix string            // What a result starting with a digit gets before it, e.g. "_" (optional)
+}
+
+func (c CharsetSanitizer) SanitizeKey(segment string) string {
+	var b strings.Builder
+	for _, r := range segment {
+		if c.Allowed(r) {
+			b.WriteRune(r)
+		} else {
+			b.WriteString(c.Replacement)
+		}
+	}
+
+	s := b.String()
+	if s != "" && '0' <= s[0] && s[0] <= '9' {
+		s = c.DigitPrefix + s
+	}
+	return s
+}
+
+// IdentifierSanitizer keeps ASCII letters, digits and underscores, replacing other characters with
+// underscores, and puts an underscore before a leading digit, as environment variables and most
+// programming languages require of names, e.g. "db.pool-size" becomes "db_pool_size".
+var IdentifierSanitizer = CharsetSanitizer{Allowed: isIdentifierRune, Replacement: "_", DigitPrefix: "_"}
+
+func isIdentifierRune(r rune) bool {
+	return 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9' || r == '_'
+}
+
+// checkSanitized fails, under StrictSanitize, if key was made before from another path which, but for
+// the sanitizers of the style, would have made another key.
+func (f *flattener) checkSanitized(key string) error {
+	if f.sanitized == nil {
+		f.sanitized = make(map[string][]interface{})
+	}
+	prior, ok := f.sanitized[key]
+	if !ok {
+		f.sanitized[key] = copyPath(f.path)
+		return nil
+	}
+	if f.rawKey(prior) == f.rawKey(f.path) {
+		return nil // alike before sanitizing too
+	}
+	return &CollisionError{Key: key, Paths: []string{pathString(prior), pathString(f.path)}}
+}
+
+// rawKey joins path, below the prefix, as the style would without its sanitizers.
+func (f *flattener) rawKey(path []interface{}) string {
+	style := f.style
+	style.Sanitizer, style.FinalSanitizer = nil, nil
+
+	key := ""
+	for i, seg := range path {
+		switch seg := seg.(type) {
+		case int:
+			key = joinIndex(i == 0, key, f.opts.index(seg), style)
+		case string:
+			key = enkey(i == 0, key, seg, style)
+		}
+	}
+	return key
+}
+
+// kebabCase lowercases a segment and separates its words with dashes, e.g. "poolSize", "pool_size" and
+// "Pool Size" all become "pool-size".  Characters other than letters and digits only separate words.
+type kebabCase struct{}
+
+func (kebabCase) SanitizeKey(segment string) string {
+	return strings.Join(words(segment), "-")
+}
+
+// A KeyTransform is a case for key segments, as Options.KeyTransform applies it to any style, e.g.
+// UpperSnakeKeys for env vars, or CamelKeys for JavaScript.  It is a KeySanitizer, for a style's own.
+type KeyTransform int
+
+const (
+	KeepKeys       KeyTransform = iota // Leave segments as they are
+	LowerKeys                          // Lowercase them, e.g. "PoolSize" to "poolsize"
+	UpperKeys                          // Uppercase them, e.g. "poolSize" to "POOLSIZE"
+	SnakeKeys                          // Join their lowercase words with underscores, e.g. "poolSize" to "pool_size"
+	UpperSnakeKeys                     // Join their uppercase words with underscores, e.g. "poolSize" to "POOL_SIZE"
+	CamelKeys                          // Join their words in lower camel case, e.g. "pool_size" to "poolSize"
+	KebabKeys                          // Join their lowercase words with dashes, e.g. "poolSize" to "pool-size"
+)
+
+func (t KeyTransform) SanitizeKey(segment string) string {
+	switch t {
+	case LowerKeys:
+		return strings.ToLower(segment)
+	case UpperKeys:
+		return strings.ToUpper(segment)
+	case SnakeKeys:
+		return strings.Join(words(segment), "_")
+	case UpperSnakeKeys:
+		return envName{}.SanitizeKey(segment)
+	case CamelKeys:
+		ws := words(segment)
+		for i := 1; i < len(ws); i++ {
+			rs := []rune(ws[i])
+			rs[0] = unicode.ToUpper(rs[0])
+			ws[i] = string(rs)
+		}
+		return strings.Join(ws, "")
+	case KebabKeys:
+		return kebabCase{}.SanitizeKey(segment)
+	}
+	return segment
+}
+
+// words splits a segment into lowercase words, at non-alphanumerics and at changes of case, such that
+// "HTTPServer2Name" becomes "http", "server2", "name".
+func words(segment string) []string {
+	var (
+		ws   []string
+		word []rune
+	)
+	rs := []rune(segment)
+
+	for i, r := range rs {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			if len(word) > 0 {
+				ws = append(ws, string(word))
+				word = word[:0]
+			}
+			continue
+		case unicode.IsUpper(r) && len(word) > 0:
+			prev := rs[i-1]
+			nextLower := i+1 < len(rs) && unicode.IsLower(rs[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				ws = append(ws, string(word))
+				word = word[:0]
+			}
+		}
+		word = append(word, unicode.ToLower(r))
+	}
+	if len(word) > 0 {
+		ws = append(ws, string(word))
+	}
+
+	return ws
+}
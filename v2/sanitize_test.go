@@ -0,0 +1,196 @@
+package flatten
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestKebabCase(t *testing.T) {
+	cases := []struct {
+		segment string
+		want    string
+	}{
+		// 1
+		{"pool", "pool"},
+		// 2
+		{"poolSize", "pool-size"},
+		// 3
+		{"pool_size", "pool-size"},
+		// 4
+		{"Pool Size", "pool-size"},
+		// 5
+		{"HTTPServer", "http-server"},
+		// 6
+		{"maxConns2Ip", "max-conns2-ip"},
+		// 7
+		{"--weird..key__", "weird-key"},
+		// 8
+		{"already-kebab", "already-kebab"},
+	}
+
+	for i, test := range cases {
+		if got := (kebabCase{}).SanitizeKey(test.segment); got != test.want {
+			t.Errorf("%d: mismatch, got: %v wanted: %v", i+1, got, test.want)
+		}
+	}
+}
+
+func TestKeyTransform(t *testing.T) {
+	cases := []struct {
+		transform KeyTransform
+		want      [3]string
+	}{
+		// 1
+		{KeepKeys, [3]string{"poolSize", "HTTPServer", "max_conns"}},
+		// 2
+		{LowerKeys, [3]string{"poolsize", "httpserver", "max_conns"}},
+		// 3
+		{UpperKeys, [3]string{"POOLSIZE", "HTTPSERVER", "MAX_CONNS"}},
+		// 4
+		{SnakeKeys, [3]string{"pool_size", "http_server", "max_conns"}},
+		// 5
+		{UpperSnakeKeys, [3]string{"POOL_SIZE", "HTTP_SERVER", "MAX_CONNS"}},
+		// 6
+		{CamelKeys, [3]string{"poolSize", "httpServer", "maxConns"}},
+		// 7
+		{KebabKeys, [3]string{"pool-size", "http-server", "max-conns"}},
+	}
+
+	for i, test := range cases {
+		var got [3]string
+		for j, segment := range []string{"poolSize", "HTTPServer", "max_conns"} {
+			got[j] = test.transform.SanitizeKey(segment)
+		}
+		if got != test.want {
+			t.Errorf("%d: mismatch, got: %v wanted: %v", i+1, got, test.want)
+		}
+	}
+
+	nested := map[string]interface{}{"dbPool": map[string]interface{}{"maxConns": 10, "hosts": []interface{}{"a"}}}
+	got, err := Options{KeyTransform: UpperSnakeKeys}.Flatten(nested, "APP_", UnderscoreStyle)
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+	want := map[string]interface{}{"APP_DB_POOL_MAX_CONNS": 10, "APP_DB_POOL_HOSTS_0": "a"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %v wanted: %v", got, want)
+	}
+
+	// before the style's own
+	got, err = Options{KeyTransform: CamelKeys}.Flatten(map[string]interface{}{"pool_size": 1}, "", SpringStyle)
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+	if want := map[string]interface{}{"pool-size": 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %v wanted: %v", got, want)
+	}
+}
+
+func TestChainSanitizers(t *testing.T) {
+	upper := KeySanitizerFunc(strings.ToUpper)
+	cut := KeySanitizerFunc(func(s string) string {
+		if len(s) > 8 {
+			return s[:8]
+		}
+		return s
+	})
+
+	cases := []struct {
+		sanitizer KeySanitizer
+		segment   string
+		want      string
+	}{
+		// 1
+		{ChainSanitizers(), "poolSize", "poolSize"},
+		// 2
+		{ChainSanitizers(kebabCase{}, upper), "poolSize", "POOL-SIZE"},
+		// 3 -- order matters
+		{ChainSanitizers(cut, kebabCase{}), "maxPoolSize", "max-pool-s"},
+		// 4
+		{ChainSanitizers(kebabCase{}, cut), "maxPoolSize", "max-pool"},
+		// 5
+		{ChainSanitizers(nil, upper, nil), "a", "A"},
+	}
+
+	for i, test := range cases {
+		if got := test.sanitizer.SanitizeKey(test.segment); got != test.want {
+			t.Errorf("%d: mismatch, got: %v wanted: %v", i+1, got, test.want)
+		}
+	}
+}
+
+func TestFinalSanitizer(t *testing.T) {
+	style := UnderscoreStyle
+	style.Sanitizer = kebabCase{}
+	style.FinalSanitizer = KeySanitizerFunc(strings.ToUpper)
+
+	got, err := Flatten(map[string]interface{}{
+		"myApp": map[string]interface{}{"poolSize": 10},
+	}, "app_", style)
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+	want := map[string]interface{}{"APP_MY-APP_POOL-SIZE": 10}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %v wanted: %v", got, want)
+	}
+}
+
+func TestCharsetSanitizer(t *testing.T) {
+	dropping := CharsetSanitizer{Allowed: func(r rune) bool { return r != '-' }}
+
+	cases := []struct {
+		sanitizer KeySanitizer
+		segment   string
+		want      string
+	}{
+		// 1
+		{IdentifierSanitizer, "pool_size", "pool_size"},
+		// 2
+		{IdentifierSanitizer, "db.pool-size", "db_pool_size"},
+		// 3
+		{IdentifierSanitizer, "2fa", "_2fa"},
+		// 4
+		{IdentifierSanitizer, "café", "caf_"},
+		// 5
+		{dropping, "a-b-3", "ab3"},
+		// 6
+		{dropping, "-9", "9"},
+	}
+
+	for i, test := range cases {
+		if got := test.sanitizer.SanitizeKey(test.segment); got != test.want {
+			t.Errorf("%d: mismatch, got: %v wanted: %v", i+1, got, test.want)
+		}
+	}
+}
+
+func TestStrictSanitize(t *testing.T) {
+	style := SeparatorStyle{Middle: "_", Sanitizer: IdentifierSanitizer}
+
+	strict := Options{StrictSanitize: true}
+	got, err := strict.Flatten(map[string]interface{}{"db.host": "h", "db": map[string]interface{}{"port": 1}}, "", style)
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+	if want := map[string]interface{}{"db_host": "h", "db_port": 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %v wanted: %v", got, want)
+	}
+
+	nested := map[string]interface{}{"db.host": "a", "db-host": "b"}
+	if _, err := (Options{}).Flatten(nested, "", style); err != nil {
+		t.Errorf("failed to flatten: %v", err)
+	}
+	_, err = strict.Flatten(nested, "", style)
+	var collision *CollisionError
+	if !errors.As(err, &collision) || !errors.Is(err, KeyCollisionError) || collision.Key != "db_host" || len(collision.Paths) != 2 {
+		t.Errorf("error mismatch, got: [%v], wanted a collision at \"db_host\"", err)
+	}
+
+	// alike before sanitizing, so not the sanitizer's doing
+	if _, err := strict.Flatten(map[string]interface{}{"a_b": 1, "a": map[string]interface{}{"b": 2}}, "", style); err != nil {
+		t.Errorf("failed to flatten: %v", err)
+	}
+}
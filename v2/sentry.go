@@ -0,0 +1,104 @@
+package flatten
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// SentryLimits bound data flattened for a Sentry event.
+type SentryLimits struct {
+	MaxDepth    int // Levels of keys to make, keeping deeper maps and slices whole (0 for no limit)
+	MaxValueLen int // Bytes per value, cutting longer strings, and JSON of whole maps and slices (0 for no limit)
+	MaxKeys     int // Keys per map, keeping the first in key order (0 for no limit)
+}
+
+// DefaultSentryLimits follow the defaults of Sentry's SDKs for normalizing event data.
+var DefaultSentryLimits = SentryLimits{MaxDepth: 3, MaxValueLen: 1024, MaxKeys: 1000}
+
+// Marks the end of a value cut short
+const sentryEllipsis = "..."
+
+// SentryExtra flattens nested with DotStyle into the "extra" data of a Sentry event, within limits.
+// Strings too long are cut, ending in an ellipsis, and whole maps and slices which are too long as
+// JSON become their cut JSON text.  The report lists what did not survive intact.
+func SentryExtra(nested map[string]interface{}, limits SentryLimits) (map[string]interface{}, LimitReport, error) {
+	var report LimitReport
+
+	flat, err := sentryFlatten(nested, "", limits, &report)
+	if err != nil {
+		return nil, report, err
+	}
+
+	return flat, report, nil
+}
+
+// SentryContexts makes the "contexts" of a Sentry event from nested: each map at its top becomes a
+// context of that name, flattened within limits as by SentryExtra.  Other top-level values, which
+// Sentry does not accept as contexts, are dropped.  The report lists what did not survive intact, with
+// keys prefixed by their context's name.
+func SentryContexts(nested map[string]interface{}, limits SentryLimits) (map[string]map[string]interface{}, LimitReport, error) {
+	var report LimitReport
+
+	names := make([]string, 0, len(nested))
+	for name := range nested {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	contexts := make(map[string]map[string]interface{})
+	for _, name := range names {
+		m, ok := nested[name].(map[string]interface{})
+		if !ok {
+			report.Dropped = append(report.Dropped, name)
+			continue
+		}
+
+		flat, err := sentryFlatten(m, name+DotStyle.Middle, limits, &report)
+		if err != nil {
+			return nil, report, err
+		}
+		contexts[name] = flat
+	}
+
+	return contexts, report, nil
+}
+
+// sentryFlatten flattens nested within limits, reporting keys with prefix, which is taken off again.
+func sentryFlatten(nested map[string]interface{}, prefix string, limits SentryLimits, report *LimitReport) (map[string]interface{}, error) {
+	f := flattener{style: DotStyle, opts: Options{MaxDepth: limits.MaxDepth}}
+
+	flat := make(map[string]interface{})
+	if err := f.flatten(true, flat, nested, "", 1); err != nil {
+		return nil, err
+	}
+
+	for i, k := range sortedKeys(flat) {
+		if limits.MaxKeys > 0 && i >= limits.MaxKeys {
+			delete(flat, k)
+			report.Dropped = append(report.Dropped, prefix+k)
+			continue
+		}
+		if limits.MaxValueLen <= 0 {
+			continue
+		}
+
+		switch v := flat[k].(type) {
+		case string:
+			if len(v) > limits.MaxValueLen {
+				flat[k] = ellipsize(v, limits.MaxValueLen, sentryEllipsis)
+				report.Truncated = append(report.Truncated, prefix+k)
+			}
+		case map[string]interface{}, []interface{}:
+			b, err := json.Marshal(v)
+			if err != nil {
+				return nil, err
+			}
+			if len(b) > limits.MaxValueLen {
+				flat[k] = ellipsize(string(b), limits.MaxValueLen, sentryEllipsis)
+				report.Truncated = append(report.Truncated, prefix+k)
+			}
+		}
+	}
+
+	return flat, nil
+}
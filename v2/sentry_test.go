@@ -0,0 +1,81 @@
+package flatten
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSentryExtra(t *testing.T) {
+	nested := map[string]interface{}{
+		"user": map[string]interface{}{
+			"id": 7.0,
+			"prefs": map[string]interface{}{
+				"theme": map[string]interface{}{"color": "dark"},
+			},
+		},
+		"query": strings.Repeat("q", 20),
+		"rows":  []interface{}{strings.Repeat("r", 30)},
+		"zzz":   "last",
+	}
+
+	limits := SentryLimits{MaxDepth: 2, MaxValueLen: 16, MaxKeys: 4}
+	got, report, err := SentryExtra(nested, limits)
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+
+	want := map[string]interface{}{
+		"query":      strings.Repeat("q", 13) + "...",
+		"rows.0":     strings.Repeat("r", 13) + "...",
+		"user.id":    7.0,
+		"user.prefs": `{"theme":{"co...`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %v wanted: %v", got, want)
+	}
+
+	wantReport := LimitReport{
+		Dropped:   []string{"zzz"},
+		Truncated: []string{"query", "rows.0", "user.prefs"},
+	}
+	if !reflect.DeepEqual(report, wantReport) {
+		t.Errorf("report mismatch, got: %v wanted: %v", report, wantReport)
+	}
+}
+
+func TestSentryContexts(t *testing.T) {
+	nested := map[string]interface{}{
+		"device": map[string]interface{}{
+			"model": "x1",
+			"screen": map[string]interface{}{
+				"width":  1080.0,
+				"height": 1920.0,
+			},
+		},
+		"app":   map[string]interface{}{"build": strings.Repeat("b", 2000)},
+		"stray": "value",
+	}
+
+	got, report, err := SentryContexts(nested, DefaultSentryLimits)
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+
+	want := map[string]map[string]interface{}{
+		"app": {"build": strings.Repeat("b", 1021) + "..."},
+		"device": {
+			"model":         "x1",
+			"screen.width":  1080.0,
+			"screen.height": 1920.0,
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %v wanted: %v", got, want)
+	}
+
+	wantReport := LimitReport{Dropped: []string{"stray"}, Truncated: []string{"app.build"}}
+	if !reflect.DeepEqual(report, wantReport) {
+		t.Errorf("report mismatch, got: %v wanted: %v", report, wantReport)
+	}
+}
@@ -0,0 +1,348 @@
+package flatten
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// A Sink receives flattened pairs one at a time, in place of a map being built.
+type Sink interface {
+	Put(key string, value interface{}) error
+}
+
+// FlattenStream flattens the JSON object read from r into sink, token by token, so the nested document
+// is never held in memory whole.  Keys and values are as for FlattenString; pairs arrive in source order.
+func FlattenStream(r io.Reader, prefix string, style SeparatorStyle, sink Sink) error {
+	s := streamer{dec: json.NewDecoder(r), style: style, sink: sink}
+
+	tok, err := s.dec.Token()
+	if err != nil {
+		if err == io.EOF {
+			return NotValidJsonInputError
+		}
+		return err
+	}
+	if tok != json.Delim('{') {
+		return NotValidJsonInputError
+	}
+	if err := s.container(json.Delim('{'), true, prefix); err != nil {
+		return err
+	}
+	if _, err := s.dec.Token(); err != io.EOF {
+		if err == nil {
+			err = fmt.Errorf("invalid data after top-level value, at offset %d", s.dec.InputOffset())
+		}
+		return err
+	}
+
+	return nil
+}
+
+// FlattenJSON flattens the JSON object read from r into a flat JSON object written to w, streaming both
+// ways, so neither document is ever held in memory whole.  Keys are written in source order; should the
+// style make one key of two paths, it is written twice, and the later value wins when read by
+// encoding/json.  Upon an error, w may hold part of the output.
+func FlattenJSON(r io.Reader, w io.Writer, prefix string, style SeparatorStyle) (err error) {
+	sink := &jsonSink{w: bufio.NewWriter(w)}
+	done := observe("FlattenJSON", 0)
+	defer func() { done(sink.n, err) }()
+
+	if err := sink.w.WriteByte('{'); err != nil {
+		return err
+	}
+	if err := FlattenStream(r, prefix, style, sink); err != nil {
+		return err
+	}
+	if err := sink.w.WriteByte('}'); err != nil {
+		return err
+	}
+
+	return sink.w.Flush()
+}
+
+// A jsonSink writes pairs as the members of a JSON object.
+type jsonSink struct {
+	w *bufio.Writer
+	n int
+}
+
+func (s *jsonSink) Put(key string, value interface{}) error {
+	k, err := json.Marshal(key)
+	if err != nil {
+		return err
+	}
+	v, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	if s.n > 0 {
+		s.w.WriteByte(',')
+	}
+	s.w.Write(k)
+	s.w.WriteByte(':')
+	_, err = s.w.Write(v)
+	s.n++
+	return err
+}
+
+// streamer flattens JSON token by token into a sink.
+type streamer struct {
+	dec   *json.Decoder
+	style SeparatorStyle
+	sink  Sink
+}
+
+// container flattens the members of an object or array, whose opening delim has been read.
+func (s *streamer) container(delim json.Delim, top bool, prefix string) error {
+	for i := 0; s.dec.More(); i++ {
+		var key string
+		if delim == '{' {
+			tok, err := s.dec.Token()
+			if err != nil {
+				return err
+			}
+			key = enkey(top, prefix, tok.(string), s.style)
+		} else {
+			key = enindex(top, prefix, i, s.style)
+		}
+
+		tok, err := s.dec.Token()
+		if err != nil {
+			return err
+		}
+
+		if d, ok := tok.(json.Delim); ok {
+			if err := s.container(d, false, key); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := s.sink.Put(s.style.finish(key), tok); err != nil {
+			return err
+		}
+	}
+
+	_, err := s.dec.Token() // the closing delim
+	return err
+}
+
+// A SpillStore is a Sink holding at most a run of pairs in memory, spilling each full run to a sorted
+// temporary file, so flattened documents larger than memory can be gathered and read back in key order.
+// Values are kept as JSON.  A key put more than once keeps its last value.
+//
+// Close removes the temporary files.
+type SpillStore struct {
+	dir     string
+	runSize int
+	buf     map[string]interface{}
+	runs    []string
+}
+
+// NewSpillStore returns a SpillStore keeping runs of up to runSize pairs, in temporary files under dir
+// (or the default temporary directory, if dir is empty).
+func NewSpillStore(dir string, runSize int) *SpillStore {
+	if runSize < 1 {
+		runSize = 1
+	}
+	return &SpillStore{dir: dir, runSize: runSize, buf: make(map[string]interface{})}
+}
+
+func (s *SpillStore) Put(key string, value interface{}) error {
+	s.buf[key] = value
+	if len(s.buf) >= s.runSize {
+		return s.spill()
+	}
+	return nil
+}
+
+// spill writes the pairs in memory to a new run file, sorted by key.
+func (s *SpillStore) spill() error {
+	if len(s.buf) == 0 {
+		return nil
+	}
+
+	f, err := os.CreateTemp(s.dir, "flatten-spill-*")
+	if err != nil {
+		return err
+	}
+	s.runs = append(s.runs, f.Name())
+
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, k := range sortedKeys(s.buf) {
+		if err := enc.Encode([]interface{}{k, s.buf[k]}); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	s.buf = make(map[string]interface{})
+	return nil
+}
+
+// Pairs spills any pairs remaining in memory, and returns an iterator over all pairs put so far, in key
+// order.  Close the iterator once done with it.
+func (s *SpillStore) Pairs() (*PairIterator, error) {
+	if err := s.spill(); err != nil {
+		return nil, err
+	}
+
+	it := &PairIterator{}
+	for i, name := range s.runs {
+		f, err := os.Open(name)
+		if err != nil {
+			it.Close()
+			return nil, err
+		}
+		r := &run{index: i, file: f, dec: json.NewDecoder(bufio.NewReader(f))}
+		it.files = append(it.files, f)
+
+		ok, err := r.advance()
+		if err != nil {
+			it.Close()
+			return nil, err
+		}
+		if ok {
+			it.runs = append(it.runs, r)
+		}
+	}
+	heap.Init(&it.runs)
+
+	return it, nil
+}
+
+// Close removes the store's temporary files.
+func (s *SpillStore) Close() error {
+	var first error
+	for _, name := range s.runs {
+		if err := os.Remove(name); err != nil && first == nil {
+			first = err
+		}
+	}
+	s.runs = nil
+	s.buf = make(map[string]interface{})
+	return first
+}
+
+// A PairIterator merges the sorted runs of a SpillStore.
+//
+//	it, err := store.Pairs()
+//	...
+//	defer it.Close()
+//	for it.Next() {
+//		fmt.Println(it.Key(), it.Value())
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type PairIterator struct {
+	runs  runHeap
+	files []*os.File
+	key   string
+	value interface{}
+	err   error
+}
+
+// Next advances to the next pair, returning false at the end or upon an error.
+func (it *PairIterator) Next() bool {
+	if it.err != nil || len(it.runs) == 0 {
+		return false
+	}
+
+	// Runs are ordered by key, and for equal keys latest first, which holds the value put last.
+	r := it.runs[0]
+	it.key, it.value = r.key, r.value
+	for len(it.runs) > 0 && it.runs[0].key == it.key {
+		r := it.runs[0]
+		ok, err := r.advance()
+		if err != nil {
+			it.err = err
+			return false
+		}
+		if ok {
+			heap.Fix(&it.runs, 0)
+		} else {
+			heap.Pop(&it.runs)
+		}
+	}
+
+	return true
+}
+
+func (it *PairIterator) Key() string        { return it.key }
+func (it *PairIterator) Value() interface{} { return it.value }
+
+// Err returns the error, if any, which ended the iteration.
+func (it *PairIterator) Err() error {
+	return it.err
+}
+
+// Close closes the iterator's run files.
+func (it *PairIterator) Close() error {
+	var first error
+	for _, f := range it.files {
+		if err := f.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	it.files = nil
+	it.runs = nil
+	return first
+}
+
+// A run reads back one spilled file, a pair at a time.
+type run struct {
+	index int
+	file  *os.File
+	dec   *json.Decoder
+	key   string
+	value interface{}
+}
+
+func (r *run) advance() (bool, error) {
+	var pair [2]interface{}
+	if err := r.dec.Decode(&pair); err != nil {
+		if err == io.EOF {
+			return false, nil
+		}
+		return false, err
+	}
+
+	key, ok := pair[0].(string)
+	if !ok {
+		return false, fmt.Errorf("corrupt spill file %s", r.file.Name())
+	}
+	r.key, r.value = key, pair[1]
+	return true, nil
+}
+
+type runHeap []*run
+
+func (h runHeap) Len() int { return len(h) }
+func (h runHeap) Less(i, j int) bool {
+	if h[i].key != h[j].key {
+		return h[i].key < h[j].key
+	}
+	return h[i].index > h[j].index
+}
+func (h runHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *runHeap) Push(x interface{}) { *h = append(*h, x.(*run)) }
+func (h *runHeap) Pop() interface{} {
+	old := *h
+	r := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return r
+}
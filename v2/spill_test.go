@@ -0,0 +1,134 @@
+package flatten
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type mapSink map[string]interface{}
+
+func (m mapSink) Put(key string, value interface{}) error {
+	m[key] = value
+	return nil
+}
+
+func TestFlattenStream(t *testing.T) {
+	cases := []struct {
+		test string
+		want map[string]interface{}
+		err  error
+	}{
+		// 1
+		{
+			`{ "a": { "b": [1, { "c": true }] }, "d": null, "e": {} }`,
+			map[string]interface{}{"a.b.0": 1.0, "a.b.1.c": true, "d": nil},
+			nil,
+		},
+		// 2
+		{`[1, 2]`, nil, NotValidJsonInputError},
+		// 3
+		{``, nil, NotValidJsonInputError},
+	}
+
+	for i, test := range cases {
+		got := mapSink{}
+		err := FlattenStream(strings.NewReader(test.test), "", DotStyle, got)
+		if err != test.err {
+			t.Errorf("%d: error mismatch, got: [%v], wanted: [%v]", i+1, err, test.err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if !reflect.DeepEqual(map[string]interface{}(got), test.want) {
+			t.Errorf("%d: mismatch, got: %v wanted: %v", i+1, got, test.want)
+		}
+	}
+}
+
+func TestFlattenStreamTrailingData(t *testing.T) {
+	if err := FlattenStream(strings.NewReader(`{"a": 1} {}`), "", DotStyle, mapSink{}); err == nil {
+		t.Errorf("trailing data accepted")
+	}
+}
+
+func TestFlattenJSON(t *testing.T) {
+	cases := []struct {
+		test   string
+		prefix string
+		want   string
+		err    error
+	}{
+		// 1
+		{`{ "a": { "b": [1, { "c": "<x>" }] }, "d": null, "e": {} }`, "", `{"a.b.0":1,"a.b.1.c":"\u003cx\u003e","d":null}`, nil},
+		// 2
+		{`{ "a": 1 }`, "p.", `{"p.a":1}`, nil},
+		// 3
+		{`{}`, "", `{}`, nil},
+		// 4
+		{`[1]`, "", ``, NotValidJsonInputError},
+	}
+
+	for i, test := range cases {
+		var b strings.Builder
+		err := FlattenJSON(strings.NewReader(test.test), &b, test.prefix, DotStyle)
+		if err != test.err {
+			t.Errorf("%d: error mismatch, got: [%v], wanted: [%v]", i+1, err, test.err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if got := b.String(); got != test.want {
+			t.Errorf("%d: mismatch, got: %v wanted: %v", i+1, got, test.want)
+		}
+	}
+}
+
+func TestSpillStore(t *testing.T) {
+	dir := t.TempDir()
+	store := NewSpillStore(dir, 2)
+
+	err := FlattenStream(strings.NewReader(`{ "e": 5, "b": 2, "d": [4], "a": 1, "c": { "x": 3 } }`), "", DotStyle, store)
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+	if err := store.Put("b", "again"); err != nil {
+		t.Fatalf("failed to put: %v", err)
+	}
+
+	it, err := store.Pairs()
+	if err != nil {
+		t.Fatalf("failed to iterate: %v", err)
+	}
+	var keys []string
+	var values []interface{}
+	for it.Next() {
+		keys = append(keys, it.Key())
+		values = append(values, it.Value())
+	}
+	if err := it.Err(); err != nil {
+		t.Errorf("iteration failed: %v", err)
+	}
+	if err := it.Close(); err != nil {
+		t.Errorf("failed to close iterator: %v", err)
+	}
+
+	wantKeys := []string{"a", "b", "c.x", "d.0", "e"}
+	wantValues := []interface{}{1.0, "again", 3.0, 4.0, 5.0}
+	if !reflect.DeepEqual(keys, wantKeys) {
+		t.Errorf("keys mismatch, got: %v wanted: %v", keys, wantKeys)
+	}
+	if !reflect.DeepEqual(values, wantValues) {
+		t.Errorf("values mismatch, got: %v wanted: %v", values, wantValues)
+	}
+
+	if err := store.Close(); err != nil {
+		t.Errorf("failed to close store: %v", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("temporary files left behind: %d", len(entries))
+	}
+}
@@ -0,0 +1,155 @@
+package flatten
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A SQLDialect sets how generated statements quote identifiers, number placeholders and upsert.
+type SQLDialect int
+
+const (
+	Postgres SQLDialect = iota // "col", $1, ON CONFLICT
+	MySQL                      // `col`, ?, ON DUPLICATE KEY
+	SQLite                     // "col", ?, ON CONFLICT
+)
+
+// SQLOptions configures InsertSQL.
+type SQLOptions struct {
+	Dialect SQLDialect
+
+	// Sanitizer makes column names of flat keys.  If nil, each character other than an ASCII letter,
+	// digit or underscore becomes an underscore, and a leading digit is prefixed with one, so that
+	// "a.b-c" becomes "a_b_c" and "0.x" becomes "_0_x".
+	Sanitizer KeySanitizer
+
+	// Conflict, if set, names the (sanitized) columns of a unique key, making the statement an upsert
+	// which updates every other column.
+	Conflict []string
+}
+
+// No keys to insert
+var NoColumnsError = errors.New("Not a valid input: no keys to insert")
+
+// Distinct keys sanitized to the same column
+var DuplicateColumnError = errors.New("Not a valid input: duplicate column")
+
+// InsertSQL returns a parameterized statement inserting flat, usually the output of Flatten, into table,
+// with one column per key in key order, and the statement's arguments.  For example, with Postgres,
+//
+//	INSERT INTO "events" ("a_b", "c") VALUES ($1, $2)
+//
+// A table name with dots is quoted as schema-qualified, part by part.
+func InsertSQL(table string, flat map[string]interface{}, opts SQLOptions) (string, []interface{}, error) {
+	if len(flat) == 0 {
+		return "", nil, NoColumnsError
+	}
+
+	sanitizer := opts.Sanitizer
+	if sanitizer == nil {
+		sanitizer = identifier{}
+	}
+
+	keys := sortedKeys(flat)
+	cols := make([]string, len(keys))
+	args := make([]interface{}, len(keys))
+	marks := make([]string, len(keys))
+	seen := make(map[string]string, len(keys))
+
+	for i, k := range keys {
+		col := sanitizer.SanitizeKey(k)
+		if other, ok := seen[col]; ok {
+			return "", nil, fmt.Errorf("%w: %q, from keys %q and %q", DuplicateColumnError, col, other, k)
+		}
+		seen[col] = k
+
+		cols[i] = opts.Dialect.quote(col)
+		args[i] = flat[k]
+		marks[i] = opts.Dialect.placeholder(i + 1)
+	}
+
+	quoted := strings.Split(table, ".")
+	for i, part := range quoted {
+		quoted[i] = opts.Dialect.quote(part)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "INSERT INTO %s (%s) VALUES (%s)",
+		strings.Join(quoted, "."), strings.Join(cols, ", "), strings.Join(marks, ", "))
+
+	if len(opts.Conflict) > 0 {
+		conflict := make(map[string]bool, len(opts.Conflict))
+		for _, col := range opts.Conflict {
+			conflict[opts.Dialect.quote(col)] = true
+		}
+
+		var sets []string
+		for _, col := range cols {
+			if !conflict[col] {
+				sets = append(sets, opts.Dialect.update(col))
+			}
+		}
+
+		if opts.Dialect == MySQL {
+			if len(sets) == 0 {
+				// MySQL has no DO NOTHING; a no-op assignment is the idiom.
+				sets = []string{cols[0] + " = " + cols[0]}
+			}
+			fmt.Fprintf(&b, " ON DUPLICATE KEY UPDATE %s", strings.Join(sets, ", "))
+		} else {
+			keyCols := make([]string, len(opts.Conflict))
+			for i, col := range opts.Conflict {
+				keyCols[i] = opts.Dialect.quote(col)
+			}
+			if len(sets) == 0 {
+				fmt.Fprintf(&b, " ON CONFLICT (%s) DO NOTHING", strings.Join(keyCols, ", "))
+			} else {
+				fmt.Fprintf(&b, " ON CONFLICT (%s) DO UPDATE SET %s", strings.Join(keyCols, ", "), strings.Join(sets, ", "))
+			}
+		}
+	}
+
+	return b.String(), args, nil
+}
+
+func (d SQLDialect) quote(ident string) string {
+	q := `"`
+	if d == MySQL {
+		q = "`"
+	}
+	return q + strings.ReplaceAll(ident, q, q+q) + q
+}
+
+func (d SQLDialect) placeholder(n int) string {
+	if d == Postgres {
+		return "$" + strconv.Itoa(n)
+	}
+	return "?"
+}
+
+func (d SQLDialect) update(col string) string {
+	if d == MySQL {
+		return col + " = VALUES(" + col + ")"
+	}
+	return col + " = EXCLUDED." + col
+}
+
+// identifier sanitizes keys into plain identifiers, of ASCII letters, digits and underscores, not
+// starting with a digit.
+type identifier struct{}
+
+func (identifier) SanitizeKey(segment string) string {
+	s := strings.Map(func(r rune) rune {
+		if 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9' || r == '_' {
+			return r
+		}
+		return '_'
+	}, segment)
+
+	if s == "" || '0' <= s[0] && s[0] <= '9' {
+		s = "_" + s
+	}
+	return s
+}
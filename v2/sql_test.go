@@ -0,0 +1,109 @@
+package flatten
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestInsertSQL(t *testing.T) {
+	flat := map[string]interface{}{
+		"id":        1.0,
+		"user.name": "jim",
+		"tags.0":    "a",
+	}
+
+	cases := []struct {
+		table string
+		opts  SQLOptions
+		want  string
+	}{
+		// 1
+		{
+			"events",
+			SQLOptions{},
+			`INSERT INTO "events" ("id", "tags_0", "user_name") VALUES ($1, $2, $3)`,
+		},
+		// 2
+		{
+			"staging.events",
+			SQLOptions{Dialect: MySQL},
+			"INSERT INTO `staging`.`events` (`id`, `tags_0`, `user_name`) VALUES (?, ?, ?)",
+		},
+		// 3
+		{
+			"events",
+			SQLOptions{Dialect: SQLite, Conflict: []string{"id"}},
+			`INSERT INTO "events" ("id", "tags_0", "user_name") VALUES (?, ?, ?)` +
+				` ON CONFLICT ("id") DO UPDATE SET "tags_0" = EXCLUDED."tags_0", "user_name" = EXCLUDED."user_name"`,
+		},
+		// 4
+		{
+			"events",
+			SQLOptions{Dialect: MySQL, Conflict: []string{"id"}},
+			"INSERT INTO `events` (`id`, `tags_0`, `user_name`) VALUES (?, ?, ?)" +
+				" ON DUPLICATE KEY UPDATE `tags_0` = VALUES(`tags_0`), `user_name` = VALUES(`user_name`)",
+		},
+		// 5 -- every column in the key
+		{
+			"events",
+			SQLOptions{Conflict: []string{"id", "tags_0", "user_name"}},
+			`INSERT INTO "events" ("id", "tags_0", "user_name") VALUES ($1, $2, $3) ON CONFLICT ("id", "tags_0", "user_name") DO NOTHING`,
+		},
+		// 6 -- a custom sanitizer, with a quote to double
+		{
+			"events",
+			SQLOptions{Sanitizer: quotingSanitizer{}},
+			`INSERT INTO "events" ("id""", "tags.0""", "user.name""") VALUES ($1, $2, $3)`,
+		},
+	}
+
+	for i, test := range cases {
+		got, args, err := InsertSQL(test.table, flat, test.opts)
+		if err != nil {
+			t.Errorf("%d: failed to build: %v", i+1, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%d: mismatch, got: %v wanted: %v", i+1, got, test.want)
+		}
+		if want := []interface{}{1.0, "a", "jim"}; !reflect.DeepEqual(args, want) {
+			t.Errorf("%d: args mismatch, got: %v wanted: %v", i+1, args, want)
+		}
+	}
+}
+
+// quotingSanitizer appends a double quote, to test quoting.
+type quotingSanitizer struct{}
+
+func (quotingSanitizer) SanitizeKey(key string) string { return key + `"` }
+
+func TestInsertSQLErrors(t *testing.T) {
+	if _, _, err := InsertSQL("t", map[string]interface{}{}, SQLOptions{}); err != NoColumnsError {
+		t.Errorf("1: error mismatch, got: [%v], wanted: [%v]", err, NoColumnsError)
+	}
+
+	flat := map[string]interface{}{"a.b": 1, "a_b": 2}
+	if _, _, err := InsertSQL("t", flat, SQLOptions{}); !errors.Is(err, DuplicateColumnError) {
+		t.Errorf("2: error mismatch, got: [%v], wanted: [%v]", err, DuplicateColumnError)
+	}
+}
+
+func TestIdentifierSanitizer(t *testing.T) {
+	cases := []struct {
+		key  string
+		want string
+	}{
+		{"plain_name", "plain_name"},
+		{"a.b-c", "a_b_c"},
+		{"0.x", "_0_x"},
+		{"café", "caf_"},
+		{"", "_"},
+	}
+
+	for i, test := range cases {
+		if got := (identifier{}).SanitizeKey(test.key); got != test.want {
+			t.Errorf("%d: mismatch, got: %v wanted: %v", i+1, got, test.want)
+		}
+	}
+}
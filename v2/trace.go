@@ -0,0 +1,151 @@
+package flatten
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// A Trace records the decisions behind each key of a flattening, for debugging why its output looks
+// the way it does.
+type Trace struct {
+	Keys map[string][]Decision // Decisions by flattened key; keys flattened plainly have none
+
+	origins map[string][]interface{} // Paths by the keys they produced, to notice collisions
+}
+
+// A Decision is one choice made while flattening a key.
+type Decision struct {
+	Kind   DecisionKind
+	Path   []interface{} // Where in the input: map keys (strings) and slice indexes (ints)
+	Detail string
+}
+
+// The kinds of Decision.
+type DecisionKind int
+
+const (
+	Sanitized   DecisionKind = iota + 1 // A key segment, or the whole key, was rewritten by a sanitizer of the style
+	Escaped                             // A key segment was escaped by the style's Escaper
+	KeptWhole                           // A map or slice was kept as a value, at the depth limit
+	Overwritten                         // A value at another path had the same key, and was replaced
+	Truncated                           // A string value was cut to the length limit
+	CycleCut                            // A map, slice or pointer was met within itself, and left nil
+	Shortened                           // A key was cut to the length limit, ending in a hash of the whole
+)
+
+func (k DecisionKind) String() string {
+	switch k {
+	case Sanitized:
+		return "sanitized"
+	case Escaped:
+		return "escaped"
+	case KeptWhole:
+		return "kept whole"
+	case Overwritten:
+		return "overwritten"
+	case Truncated:
+		return "truncated"
+	case CycleCut:
+		return "cycle cut"
+	case Shortened:
+		return "shortened"
+	}
+	return "DecisionKind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// Explain is Flatten, also returning a Trace of the decisions behind each flattened key.
+func Explain(nested map[string]interface{}, prefix string, style SeparatorStyle) (map[string]interface{}, *Trace, error) {
+	trace := &Trace{}
+	f := flattener{style: style, trace: trace}
+
+	flatmap := make(map[string]interface{})
+	if err := f.flatten(true, flatmap, nested, prefix, 1); err != nil {
+		return nil, nil, err
+	}
+
+	return flatmap, trace, nil
+}
+
+// String lists the decisions, one per line and by key, e.g.
+//
+//	my-app.pool-size: sanitized at .myApp: "myApp" -> "my-app"
+func (t *Trace) String() string {
+	keys := make([]string, 0, len(t.Keys))
+	for k := range t.Keys {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		for _, d := range t.Keys[k] {
+			fmt.Fprintf(&b, "%s: %v at %s: %s\n", k, d.Kind, pathString(d.Path), d.Detail)
+		}
+	}
+	return b.String()
+}
+
+func (t *Trace) add(key string, kind DecisionKind, path []interface{}, detail string) {
+	if t.Keys == nil {
+		t.Keys = make(map[string][]Decision)
+	}
+	t.Keys[key] = append(t.Keys[key], Decision{Kind: kind, Path: copyPath(path), Detail: detail})
+}
+
+// leaf records the decisions behind the key of a leaf at path, joined as joined and finished as key.
+func (t *Trace) leaf(joined, key string, path []interface{}, style SeparatorStyle) {
+	for i, seg := range path {
+		s, ok := seg.(string)
+		if !ok {
+			continue
+		}
+
+		if style.Sanitizer != nil {
+			if sanitized := style.Sanitizer.SanitizeKey(s); sanitized != s {
+				t.add(key, Sanitized, path[:i+1], fmt.Sprintf("%q -> %q", s, sanitized))
+				s = sanitized
+			}
+		}
+		if style.Escaper != nil {
+			if escaped := style.Escaper.Escape(s); escaped != s {
+				t.add(key, Escaped, path[:i+1], fmt.Sprintf("%q -> %q", s, escaped))
+			}
+		}
+	}
+
+	if key != joined {
+		t.add(key, Sanitized, path, fmt.Sprintf("whole key %q -> %q", joined, key))
+	}
+
+	if t.origins == nil {
+		t.origins = make(map[string][]interface{})
+	}
+	if prior, ok := t.origins[key]; ok {
+		t.add(key, Overwritten, prior, "replaced by the value at "+pathString(path))
+	}
+	t.origins[key] = copyPath(path)
+}
+
+func copyPath(path []interface{}) []interface{} {
+	return append([]interface{}(nil), path...)
+}
+
+// pathString writes a path as jq would, e.g. .a."b c"[0].
+func pathString(path []interface{}) string {
+	if len(path) == 0 {
+		return "."
+	}
+
+	var b strings.Builder
+	for _, seg := range path {
+		switch seg := seg.(type) {
+		case int:
+			b.WriteString("[" + strconv.Itoa(seg) + "]")
+		default:
+			b.WriteString("." + (jqQuoter{}).Escape(fmt.Sprint(seg)))
+		}
+	}
+	return b.String()
+}
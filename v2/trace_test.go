@@ -0,0 +1,80 @@
+package flatten
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	nested := map[string]interface{}{
+		"myApp": map[string]interface{}{
+			"poolSize": 10.0,
+			"plain":    "x",
+		},
+		"list": []interface{}{"a"},
+	}
+
+	got, trace, err := Explain(nested, "", SpringStyle)
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+
+	want, _ := Flatten(nested, "", SpringStyle)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %v wanted: %v", got, want)
+	}
+
+	wantKeys := map[string][]Decision{
+		"my-app.pool-size": {
+			{Sanitized, []interface{}{"myApp"}, `"myApp" -> "my-app"`},
+			{Sanitized, []interface{}{"myApp", "poolSize"}, `"poolSize" -> "pool-size"`},
+		},
+		"my-app.plain": {
+			{Sanitized, []interface{}{"myApp"}, `"myApp" -> "my-app"`},
+		},
+	}
+	if !reflect.DeepEqual(trace.Keys, wantKeys) {
+		t.Errorf("trace mismatch, got: %v wanted: %v", trace.Keys, wantKeys)
+	}
+}
+
+func TestExplainEscapedAndOverwritten(t *testing.T) {
+	nested := map[string]interface{}{
+		"a.b": 1.0,
+		"a":   map[string]interface{}{"b": 2.0},
+	}
+
+	_, trace, err := Explain(nested, "", SeparatorStyle{Middle: ".", Escaper: PercentEscaper("%")})
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+
+	decisions := trace.Keys["a.b"]
+	if len(decisions) != 1 || decisions[0].Kind != Overwritten {
+		t.Errorf("1: trace mismatch, got: %v wanted: one overwrite", decisions)
+	}
+
+	_, trace, err = Explain(map[string]interface{}{"x.y": 1.0}, "", SeparatorStyle{Middle: ".", Escaper: PercentEscaper(".")})
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+	if want := "x%2Ey: escaped at .\"x.y\": \"x.y\" -> \"x%2Ey\"\n"; trace.String() != want {
+		t.Errorf("2: trace mismatch, got: %q wanted: %q", trace.String(), want)
+	}
+}
+
+func TestPathString(t *testing.T) {
+	cases := []struct {
+		path []interface{}
+		want string
+	}{
+		{nil, "."},
+		{[]interface{}{"a", 0, "b c"}, `.a[0]."b c"`},
+	}
+
+	for i, test := range cases {
+		if got := pathString(test.path); got != test.want {
+			t.Errorf("%d: mismatch, got: %v wanted: %v", i+1, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package flatten
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Unflatten builds a nested map from a flat one, reversing Flatten: each key is split into segments by
+// style, and maps whose keys are exactly the indexes 0 through n-1 become slices.  Segments are unescaped
+// by the style's Escaper, if any; sanitized keys stay as sanitized.
+func Unflatten(flat map[string]interface{}, style SeparatorStyle) (map[string]interface{}, error) {
+	done := observe("Unflatten", len(flat))
+
+	nested, err := nest(flat, style.SplitKey)
+	done(len(flat), err)
+	if err != nil {
+		return nil, err
+	}
+
+	return nested, nil
+}
+
+// UnflattenString generates nested JSON from a flat JSON map, reversing FlattenString: the prefix is
+// stripped from each key, which must have it, and the rest split by style.
+func UnflattenString(flatstr, prefix string, style SeparatorStyle) (nestedstr string, err error) {
+	done := observe("UnflattenString", len(flatstr))
+	keys := 0
+	defer func() { done(keys, err) }()
+
+	if !isJsonMap.MatchString(flatstr) {
+		return "", NotValidJsonInputError
+	}
+
+	var flat map[string]interface{}
+	if err := json.Unmarshal([]byte(flatstr), &flat); err != nil {
+		return "", err
+	}
+	keys = len(flat)
+
+	nested, err := nest(flat, func(key string) ([]string, error) {
+		if !strings.HasPrefix(key, prefix) {
+			return nil, fmt.Errorf("%w: %q lacks prefix %q", NotValidKeyError, key, prefix)
+		}
+		return style.SplitKey(key[len(prefix):])
+	})
+	if err != nil {
+		return "", err
+	}
+
+	nestedb, err := json.Marshal(&nested)
+	if err != nil {
+		return "", err
+	}
+
+	return string(nestedb), nil
+}
+
+// UnflattenReader builds a nested map from a dump of flat pairs, one per line, without first holding them
+// as a flat map.  Each line is either key=value, the value a string, or a JSON object of flat keys and
+// values, e.g. {"a.b": 1}.  Blank lines are skipped.  Keys are split by style, as Unflatten does.
+func UnflattenReader(r io.Reader, style SeparatorStyle) (map[string]interface{}, error) {
+	return Options{}.UnflattenReader(r, style)
+}
+
+// UnflattenReader builds a nested map from a dump of flat pairs, as the package-level UnflattenReader
+// does, under the options.
+func (o Options) UnflattenReader(r io.Reader, style SeparatorStyle) (nested map[string]interface{}, err error) {
+	done := observe("Options.UnflattenReader", 0)
+	keys := 0
+	defer func() { done(keys, err) }()
+
+	style = o.styled(style)
+	root := make(map[string]interface{})
+	put := func(key string, v interface{}) error {
+		path, err := style.SplitKey(key)
+		if err != nil {
+			return err
+		}
+		if err := o.Conflicts.nestValue(root, path, o.coerce(key, v)); err != nil {
+			err.Key = key
+			return err
+		}
+		keys++
+		return nil
+	}
+
+	br := bufio.NewReader(r)
+	for n := 1; ; n++ {
+		line, readErr := br.ReadString('\n')
+		if readErr != nil && readErr != io.EOF {
+			return nil, readErr
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+		switch {
+		case strings.TrimSpace(line) == "":
+		case strings.HasPrefix(strings.TrimSpace(line), "{"):
+			var record map[string]interface{}
+			if err := json.Unmarshal([]byte(line), &record); err != nil {
+				return nil, fmt.Errorf("line %d: %w", n, err)
+			}
+			for k, v := range record {
+				if err := put(k, v); err != nil {
+					return nil, fmt.Errorf("line %d: %w", n, err)
+				}
+			}
+		default:
+			eq := strings.IndexByte(line, '=')
+			if eq < 0 {
+				return nil, fmt.Errorf("line %d: %w: %q lacks '='", n, NotValidKeyError, line)
+			}
+			if err := put(line[:eq], line[eq+1:]); err != nil {
+				return nil, fmt.Errorf("line %d: %w", n, err)
+			}
+		}
+
+		if readErr == io.EOF {
+			break
+		}
+	}
+
+	for k, v := range root {
+		root[k] = o.toSlices(v)
+	}
+
+	return root, nil
+}
@@ -0,0 +1,442 @@
+package flatten
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUnflatten(t *testing.T) {
+	cases := []struct {
+		flat  map[string]interface{}
+		style SeparatorStyle
+		want  map[string]interface{}
+		err   error
+	}{
+		// 1
+		{
+			map[string]interface{}{"a.b": "c", "a.l.0": 1.0, "a.l.1.d": true, "e": nil},
+			DotStyle,
+			map[string]interface{}{
+				"a": map[string]interface{}{
+					"b": "c",
+					"l": []interface{}{1.0, map[string]interface{}{"d": true}},
+				},
+				"e": nil,
+			},
+			nil,
+		},
+		// 2
+		{
+			map[string]interface{}{"a[b]": "c", "a%5B0%5D[x]": "y"},
+			RailsStyle,
+			map[string]interface{}{
+				"a":    map[string]interface{}{"b": "c"},
+				"a[0]": map[string]interface{}{"x": "y"},
+			},
+			nil,
+		},
+		// 3
+		{
+			map[string]interface{}{"db_host": "h", "db_ports_0": "1"},
+			UnderscoreStyle,
+			map[string]interface{}{"db": map[string]interface{}{"host": "h", "ports": []interface{}{"1"}}},
+			nil,
+		},
+		// 4
+		{
+			map[string]interface{}{"a": 1.0, "a.b": 2.0},
+			DotStyle,
+			nil,
+			LeafParentConflictError,
+		},
+		// 5
+		{
+			map[string]interface{}{"a[b": 1.0},
+			RailsStyle,
+			nil,
+			NotValidKeyError,
+		},
+	}
+
+	for i, test := range cases {
+		got, err := Unflatten(test.flat, test.style)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%d: error mismatch, got: [%v], wanted: [%v]", i+1, err, test.err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%d: mismatch, got: %v wanted: %v", i+1, got, test.want)
+		}
+	}
+}
+
+func TestUnflattenRoundTrip(t *testing.T) {
+	var nested map[string]interface{}
+	err := json.Unmarshal([]byte(`{
+		"server": { "hosts": ["a", "b"], "tls": { "on": true } },
+		"a[0]": { "b]": "c", "50%": "d" }
+	}`), &nested)
+	if err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+
+	for i, style := range []SeparatorStyle{RailsStyle, URIPathStyle, HelmStyle, BackslashStyle} {
+		flat, err := Flatten(nested, "", style)
+		if err != nil {
+			t.Errorf("%d: failed to flatten: %v", i+1, err)
+			continue
+		}
+		got, err := Unflatten(flat, style)
+		if err != nil {
+			t.Errorf("%d: failed to unflatten: %v", i+1, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, nested) {
+			t.Errorf("%d: mismatch, got: %v wanted: %v", i+1, got, nested)
+		}
+	}
+}
+
+func TestUnflattenString(t *testing.T) {
+	cases := []struct {
+		test   string
+		want   string
+		prefix string
+		style  SeparatorStyle
+		err    error
+	}{
+		// 1
+		{
+			`{ "a.b.c.d": "e", "bool": true, "number": 1.4567 }`,
+			`{"a":{"b":{"c":{"d":"e"}}},"bool":true,"number":1.4567}`,
+			"",
+			DotStyle,
+			nil,
+		},
+		// 2
+		{
+			`{ "flag-a/0": "x", "flag-a/1/b": "y" }`,
+			`{"a":["x",{"b":"y"}]}`,
+			"flag-",
+			PathStyle,
+			nil,
+		},
+		// 3
+		{
+			`{ "flag-a": "x", "b": "y" }`,
+			``,
+			"flag-",
+			DotStyle,
+			NotValidKeyError,
+		},
+		// 4
+		{
+			`[ "a.b" ]`,
+			``,
+			"",
+			DotStyle,
+			NotValidJsonInputError,
+		},
+	}
+
+	for i, test := range cases {
+		got, err := UnflattenString(test.test, test.prefix, test.style)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%d: error mismatch, got: [%v], wanted: [%v]", i+1, err, test.err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%d: mismatch, got: %v wanted: %v", i+1, got, test.want)
+		}
+	}
+}
+
+func TestSparseArrays(t *testing.T) {
+	flat := map[string]interface{}{"a.0": "x", "a.3": "y", "b.1": "z", "c.0": "w", "c.1": "v"}
+
+	cases := []struct {
+		policy SparseArrayPolicy
+		want   map[string]interface{}
+	}{
+		// 1
+		{
+			SparseAsMap,
+			map[string]interface{}{
+				"a": map[string]interface{}{"0": "x", "3": "y"},
+				"b": map[string]interface{}{"1": "z"},
+				"c": []interface{}{"w", "v"},
+			},
+		},
+		// 2
+		{
+			SparseFillNil,
+			map[string]interface{}{
+				"a": []interface{}{"x", nil, nil, "y"},
+				"b": []interface{}{nil, "z"},
+				"c": []interface{}{"w", "v"},
+			},
+		},
+		// 3
+		{
+			SparseCompact,
+			map[string]interface{}{
+				"a": []interface{}{"x", "y"},
+				"b": []interface{}{"z"},
+				"c": []interface{}{"w", "v"},
+			},
+		},
+	}
+
+	for i, test := range cases {
+		got, err := Options{SparseArrays: test.policy}.Unflatten(flat, DotStyle)
+		if err != nil {
+			t.Errorf("%d: failed to unflatten: %v", i+1, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%d: mismatch, got: %v wanted: %v", i+1, got, test.want)
+		}
+	}
+}
+
+func TestSparseFillNilLimit(t *testing.T) {
+	got, err := Options{SparseArrays: SparseFillNil}.Unflatten(map[string]interface{}{"a.100000000": "x"}, DotStyle)
+	if err != nil {
+		t.Fatalf("failed to unflatten: %v", err)
+	}
+	want := map[string]interface{}{"a": map[string]interface{}{"100000000": "x"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %v wanted: %v", got, want)
+	}
+}
+
+func TestConflicts(t *testing.T) {
+	flat := map[string]interface{}{"a": 1.0, "a.b": 2.0, "a.c.d": 3.0, "e": nil, "e.f": 4.0}
+
+	cases := []struct {
+		strategy ConflictStrategy
+		want     map[string]interface{}
+	}{
+		// 1
+		{
+			PreferNested,
+			map[string]interface{}{
+				"a": map[string]interface{}{"b": 2.0, "c": map[string]interface{}{"d": 3.0}},
+				"e": map[string]interface{}{"f": 4.0},
+			},
+		},
+		// 2
+		{
+			PreferScalar,
+			map[string]interface{}{"a": 1.0, "e": nil},
+		},
+	}
+
+	for i, test := range cases {
+		// Map order varies, so try a few
+		for try := 0; try < 20; try++ {
+			got, err := Options{Conflicts: test.strategy}.Unflatten(flat, DotStyle)
+			if err != nil {
+				t.Errorf("%d: failed to unflatten: %v", i+1, err)
+				break
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("%d: mismatch, got: %v wanted: %v", i+1, got, test.want)
+				break
+			}
+		}
+	}
+}
+
+func TestConflictError(t *testing.T) {
+	_, err := Unflatten(map[string]interface{}{"a.b": 1.0, "a.b.c": 2.0}, DotStyle)
+
+	var conflict *ConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("error mismatch, got: [%v], wanted a *ConflictError", err)
+	}
+	if !errors.Is(err, LeafParentConflictError) {
+		t.Errorf("error mismatch, got: [%v], wanted: [%v]", err, LeafParentConflictError)
+	}
+	// Whichever key comes first, the conflict is at "a.b"
+	if want := []string{"a", "b"}; !reflect.DeepEqual(conflict.Path, want) {
+		t.Errorf("path mismatch, got: %q wanted: %q", conflict.Path, want)
+	}
+}
+
+func TestEscapeSeparators(t *testing.T) {
+	nested := map[string]interface{}{
+		"k8s.io": map[string]interface{}{"a/b": "c", "50%": []interface{}{"d"}},
+		"plain":  "e",
+	}
+	opts := Options{EscapeSeparators: true}
+
+	for i, style := range []SeparatorStyle{DotStyle, PathStyle, UnderscoreStyle, {Before: "(", After: ")"}} {
+		flat, err := opts.Flatten(nested, "", style)
+		if err != nil {
+			t.Errorf("%d: failed to flatten: %v", i+1, err)
+			continue
+		}
+		got, err := opts.Unflatten(flat, style)
+		if err != nil {
+			t.Errorf("%d: failed to unflatten: %v", i+1, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, nested) {
+			t.Errorf("%d: mismatch, got: %v wanted: %v", i+1, got, nested)
+		}
+	}
+
+	flat, _ := opts.Flatten(nested, "", DotStyle)
+	want := map[string]interface{}{"k8s%2Eio.a/b": "c", "k8s%2Eio.50%25.0": "d", "plain": "e"}
+	if !reflect.DeepEqual(flat, want) {
+		t.Errorf("mismatch, got: %v wanted: %v", flat, want)
+	}
+}
+
+func TestSeparatorEscape(t *testing.T) {
+	nested := map[string]interface{}{
+		"k8s.io": map[string]interface{}{"a/b": "c", `x\y`: []interface{}{"d"}, "~": "t"},
+		"plain":  "e",
+	}
+
+	for _, mark := range []string{`\`, "~", "^^"} {
+		opts := Options{EscapeSeparators: true, SeparatorEscape: mark}
+		for i, style := range []SeparatorStyle{DotStyle, PathStyle, {Before: "(", After: ")"}, {Middle: ".", IndexBefore: "[", IndexAfter: "]"}} {
+			flat, err := opts.Flatten(nested, "", style)
+			if err != nil {
+				t.Errorf("%s %d: failed to flatten: %v", mark, i+1, err)
+				continue
+			}
+			got, err := opts.Unflatten(flat, style)
+			if err != nil {
+				t.Errorf("%s %d: failed to unflatten: %v", mark, i+1, err)
+				continue
+			}
+			if !reflect.DeepEqual(got, nested) {
+				t.Errorf("%s %d: mismatch, got: %v wanted: %v", mark, i+1, got, nested)
+			}
+		}
+	}
+
+	flat, _ := Options{EscapeSeparators: true, SeparatorEscape: `\`}.Flatten(nested, "", DotStyle)
+	want := map[string]interface{}{`k8s\.io.a/b`: "c", `k8s\.io.x\\y.0`: "d", `k8s\.io.~`: "t", "plain": "e"}
+	if !reflect.DeepEqual(flat, want) {
+		t.Errorf("mismatch, got: %v wanted: %v", flat, want)
+	}
+
+	// A mark holding a separator is ignored, for percent-encoding
+	percent, _ := Options{EscapeSeparators: true}.Flatten(nested, "", DotStyle)
+	for i, mark := range []string{".", "x."} {
+		opts := Options{EscapeSeparators: true, SeparatorEscape: mark}
+		flat, err := opts.Flatten(nested, "", DotStyle)
+		if err != nil {
+			t.Errorf("%d: failed to flatten: %v", i+1, err)
+			continue
+		}
+		if !reflect.DeepEqual(flat, percent) {
+			t.Errorf("%d: mismatch, got: %v wanted: %v", i+1, flat, percent)
+		}
+		if got, err := opts.Unflatten(flat, DotStyle); err != nil || !reflect.DeepEqual(got, nested) {
+			t.Errorf("%d: round trip mismatch, got: %v, %v wanted: %v", i+1, got, err, nested)
+		}
+	}
+}
+
+func TestCoerceValues(t *testing.T) {
+	flat := map[string]interface{}{
+		"on":    "true",
+		"off":   "false",
+		"n":     "42",
+		"neg":   "-7",
+		"f":     "1.5",
+		"small": "0.25",
+		"exp":   "1e3",
+		"zip":   "01234",
+		"word":  "True",
+		"inf":   "1e999",
+		"nan":   "NaN",
+		"empty": "",
+		"kept":  "99",
+		"num":   3.0,
+	}
+	opts := Options{
+		CoerceValues: true,
+		KeepString:   func(key string) bool { return key == "kept" },
+	}
+
+	got, err := opts.Unflatten(flat, DotStyle)
+	if err != nil {
+		t.Fatalf("failed to unflatten: %v", err)
+	}
+	want := map[string]interface{}{
+		"on":    true,
+		"off":   false,
+		"n":     42,
+		"neg":   -7,
+		"f":     1.5,
+		"small": 0.25,
+		"exp":   1000.0,
+		"zip":   "01234",
+		"word":  "True",
+		"inf":   "1e999",
+		"nan":   "NaN",
+		"empty": "",
+		"kept":  "99",
+		"num":   3.0,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %v wanted: %v", got, want)
+	}
+}
+
+func TestUnflattenReader(t *testing.T) {
+	dump := "server.host=db.local\r\n" +
+		"\n" +
+		`{"server.port": 5432, "server.tls": true}` + "\n" +
+		"server.hosts.0=a\n" +
+		"server.hosts.1=b=c\n" +
+		`{"server.opts": {"x": null}}`
+
+	got, err := UnflattenReader(strings.NewReader(dump), DotStyle)
+	if err != nil {
+		t.Fatalf("failed to unflatten: %v", err)
+	}
+	want := map[string]interface{}{
+		"server": map[string]interface{}{
+			"host":  "db.local",
+			"port":  5432.0,
+			"tls":   true,
+			"hosts": []interface{}{"a", "b=c"},
+			"opts":  map[string]interface{}{"x": nil},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %v wanted: %v", got, want)
+	}
+}
+
+func TestUnflattenReaderErrors(t *testing.T) {
+	cases := []struct {
+		dump string
+		err  error
+		line string
+	}{
+		// 1
+		{"a=1\nnoequals\n", NotValidKeyError, "line 2"},
+		// 2
+		{"a=1\na.b=2\n", LeafParentConflictError, "line 2"},
+		// 3
+		{"{\"a\": \n", nil, "line 1"},
+	}
+
+	for i, test := range cases {
+		_, err := UnflattenReader(strings.NewReader(test.dump), DotStyle)
+		if err == nil || (test.err != nil && !errors.Is(err, test.err)) || !strings.HasPrefix(err.Error(), test.line) {
+			t.Errorf("%d: error mismatch, got: [%v], wanted: [%v] on %s", i+1, err, test.err, test.line)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package flatten
+
+// An Option sets up a call to FlattenWithOptions.  New settings arrive as new Options, so the signature
+// never has to change.
+type Option func(*settings)
+
+// The settings of a call to FlattenWithOptions
+type settings struct {
+	prefix   string
+	style    SeparatorStyle
+	pipeline *Pipeline
+	trace    *Trace
+	opts     Options
+}
+
+// WithPrefix joins prefix to each key.
+func WithPrefix(prefix string) Option {
+	return func(s *settings) { s.prefix = prefix }
+}
+
+// WithStyle presents keys in style, rather than DotStyle.
+func WithStyle(style SeparatorStyle) Option {
+	return func(s *settings) { s.style = style }
+}
+
+// WithMaxDepth makes at most n levels of keys, keeping deeper maps and slices whole, as values (0 for no
+// limit), as Options.MaxDepth does.
+func WithMaxDepth(n int) Option {
+	return func(s *settings) { s.opts.MaxDepth = n }
+}
+
+// MaxKeys fails with a *KeyLimitError rather than make more than n keys, as Options.MaxKeys does,
+// bounding the memory a hostile document, e.g. one of huge arrays, can take.
+func MaxKeys(n int) Option {
+	return func(s *settings) { s.opts.MaxKeys = n }
+}
+
+// MaxKeyLen makes keys at most n bytes long, shortening longer ones or failing as policy says, as
+// Options.MaxKeyLen and KeyOverflow do.
+func MaxKeyLen(n int, policy KeyLenPolicy) Option {
+	return func(s *settings) { s.opts.MaxKeyLen, s.opts.KeyOverflow = n, policy }
+}
+
+// OmitNulls leaves out keys whose value is null, as Options.OmitNulls does.
+func OmitNulls() Option {
+	return func(s *settings) { s.opts.OmitNulls = true }
+}
+
+// WithFilter keeps only the values, maps and slices included, for which keep returns true, as a
+// Pipeline's Filter does.
+func WithFilter(keep func(path []interface{}, v interface{}) bool) Option {
+	return func(s *settings) {
+		p := s.stages()
+		p.Filter = keep
+	}
+}
+
+// WithPipeline passes each value through the stages of p, as Pipeline.Flatten does.  The Prefix and
+// Style of p are not used; see WithPrefix and WithStyle.
+func WithPipeline(p Pipeline) Option {
+	return func(s *settings) { s.pipeline = &p }
+}
+
+// WithTrace records the decisions behind each key in t, as Explain does.
+func WithTrace(t *Trace) Option {
+	return func(s *settings) { s.trace = t }
+}
+
+// WithOptions flattens under o, e.g. its Bytes encoding or MaxValueLen, replacing the settings of
+// earlier WithMaxDepth, MaxKeys, MaxKeyLen and OmitNulls Options.
+func WithOptions(o Options) Option {
+	return func(s *settings) { s.opts = o }
+}
+
+// stages returns the pipeline of s, adding one if need be.
+func (s *settings) stages() *Pipeline {
+	if s.pipeline == nil {
+		s.pipeline = &Pipeline{}
+	}
+	return s.pipeline
+}
+
+// FlattenWithOptions generates a flat map from a nested one, as Flatten does, set up by opts, in order,
+// so later ones override earlier.  Without any, keys are joined in DotStyle, without a prefix, e.g.
+//
+//	flat, err := flatten.FlattenWithOptions(nested, flatten.WithPrefix("app"), flatten.WithMaxDepth(2))
+func FlattenWithOptions(nested map[string]interface{}, opts ...Option) (map[string]interface{}, error) {
+	s := settings{style: DotStyle}
+	for _, opt := range opts {
+		opt(&s)
+	}
+	return s.flatten("FlattenWithOptions", nested)
+}
+
+// flatten generates a flat map from a nested one, under the settings, observed as op.
+func (s settings) flatten(op string, nested map[string]interface{}) (map[string]interface{}, error) {
+	flatmap := make(map[string]interface{})
+	if err := s.flattenInto(op, flatmap, nested); err != nil {
+		return nil, err
+	}
+
+	return flatmap, nil
+}
+
+// flattenPartial generates a flat map from a nested one, under the settings, observed as op, returning
+// the keys flattened until an error as well.
+func (s settings) flattenPartial(op string, nested map[string]interface{}) (map[string]interface{}, error) {
+	flatmap := make(map[string]interface{})
+	err := s.flattenInto(op, flatmap, nested)
+
+	return flatmap, err
+}
+
+// flattenInto flattens a nested map into flatmap, under the settings, observed as op.
+func (s settings) flattenInto(op string, flatmap, nested map[string]interface{}) error {
+	done := observe(op, len(nested))
+
+	f := flattener{style: s.opts.styled(s.style), trace: s.trace, pipeline: s.pipeline, opts: s.opts}
+	err := f.flatten(true, flatmap, nested, s.prefix, 1)
+	done(len(flatmap), err)
+
+	return err
+}
@@ -0,0 +1,81 @@
+package flatten
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFlattenWithOptions(t *testing.T) {
+	nested := map[string]interface{}{
+		"a": map[string]interface{}{"b": map[string]interface{}{"c": "deep"}},
+		"s": "secret",
+		"l": []interface{}{1.0, "x"},
+		"n": nil,
+	}
+
+	var trace Trace
+	cases := []struct {
+		opts []Option
+		want map[string]interface{}
+	}{
+		// 1
+		{
+			nil,
+			map[string]interface{}{"a.b.c": "deep", "s": "secret", "l.0": 1.0, "l.1": "x", "n": nil},
+		},
+		// 2
+		{
+			[]Option{WithPrefix("app_"), WithStyle(UnderscoreStyle)},
+			map[string]interface{}{"app_a_b_c": "deep", "app_s": "secret", "app_l_0": 1.0, "app_l_1": "x", "app_n": nil},
+		},
+		// 3
+		{
+			[]Option{WithMaxDepth(2), WithTrace(&trace)},
+			map[string]interface{}{"a.b": map[string]interface{}{"c": "deep"}, "s": "secret", "l.0": 1.0, "l.1": "x", "n": nil},
+		},
+		// 4
+		{
+			[]Option{WithFilter(func(path []interface{}, v interface{}) bool { return path[0] != "l" })},
+			map[string]interface{}{"a.b.c": "deep", "s": "secret", "n": nil},
+		},
+		// 5
+		{
+			[]Option{
+				WithPipeline(Pipeline{Redact: func(path []interface{}, v interface{}) bool { return path[0] == "s" }, Redaction: "***"}),
+				WithOptions(Options{MaxValueLen: 2, Ellipsis: "~"}), // the ellipsis counts,
+			},
+			map[string]interface{}{"a.b.c": "d~", "s": "*~", "l.0": 1.0, "l.1": "x", "n": nil},
+		},
+		// 6
+		{
+			[]Option{OmitNulls()},
+			map[string]interface{}{"a.b.c": "deep", "s": "secret", "l.0": 1.0, "l.1": "x"},
+		},
+		// 7 -- later options win
+		{
+			[]Option{WithStyle(UnderscoreStyle), WithStyle(DotStyle), WithFilter(func([]interface{}, interface{}) bool { return false })},
+			map[string]interface{}{},
+		},
+		// 8 -- a limit just met
+		{
+			[]Option{MaxKeys(5)},
+			map[string]interface{}{"a.b.c": "deep", "s": "secret", "l.0": 1.0, "l.1": "x", "n": nil},
+		},
+	}
+
+	for i, test := range cases {
+		got, err := FlattenWithOptions(nested, test.opts...)
+		if err != nil {
+			t.Errorf("%d: failed to flatten: %v", i+1, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%d: mismatch, got: %v wanted: %v", i+1, got, test.want)
+		}
+	}
+
+	if s := trace.String(); !strings.Contains(s, "kept whole") {
+		t.Errorf("mismatch, got: %q wanted a kept whole decision", s)
+	}
+}
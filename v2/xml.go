@@ -0,0 +1,116 @@
+package flatten
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+)
+
+// XML input must have a single root element
+var NotValidXMLInputError = errors.New("Not a valid input: XML must have one root element")
+
+// An XMLConvention says how the parts of an element which are not child elements are keyed.
+type XMLConvention struct {
+	Attr string // Prepend to attribute names, e.g. "@" for "a.b.@id"
+	Text string // Key of the text of an element with attributes or children, e.g. "#text" for "a.b.#text"
+}
+
+// The conventions of most XML to JSON converters, e.g. "feed.entry.0.@id" and "feed.entry.0.title.#text"
+var DefaultXMLConvention = XMLConvention{Attr: "@", Text: "#text"}
+
+// FlattenXML generates a flat map from the XML document read from r, the root element its first key.
+// An element with neither attributes nor children is a string value, its text; otherwise it is a map of
+// its attributes and children, with any text, by conv.  Elements repeated under one parent become a
+// slice, indexed in document order; single ones are not indexed.  Names are local, without namespaces.
+// The text of an element is all its own, around any children, trimmed of surrounding space.
+func FlattenXML(r io.Reader, prefix string, style SeparatorStyle, conv XMLConvention) (flatmap map[string]interface{}, err error) {
+	done := observe("FlattenXML", 0)
+	defer func() { done(len(flatmap), err) }()
+
+	nested, err := nestXML(xml.NewDecoder(r), conv)
+	if err != nil {
+		return nil, err
+	}
+
+	flatmap = make(map[string]interface{})
+	if err := flatten(true, flatmap, nested, prefix, style); err != nil {
+		return nil, err
+	}
+
+	return flatmap, nil
+}
+
+// An xmlElement is an element being read.
+type xmlElement struct {
+	name    string
+	members map[string]interface{}
+	text    strings.Builder
+}
+
+func nestXML(dec *xml.Decoder, conv XMLConvention) (map[string]interface{}, error) {
+	var root map[string]interface{}
+	var open []*xmlElement
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			if root != nil {
+				return nil, NotValidXMLInputError
+			}
+			e := &xmlElement{name: tok.Name.Local, members: make(map[string]interface{})}
+			for _, attr := range tok.Attr {
+				if attr.Name.Space == "xmlns" || attr.Name.Local == "xmlns" {
+					continue
+				}
+				e.members[conv.Attr+attr.Name.Local] = attr.Value
+			}
+			open = append(open, e)
+		case xml.CharData:
+			if len(open) > 0 {
+				open[len(open)-1].text.Write(tok)
+			} else if strings.TrimSpace(string(tok)) != "" {
+				return nil, NotValidXMLInputError
+			}
+		case xml.EndElement:
+			e := open[len(open)-1]
+			open = open[:len(open)-1]
+
+			text := strings.TrimSpace(e.text.String())
+			var v interface{} = text
+			if len(e.members) > 0 {
+				if text != "" {
+					e.members[conv.Text] = text
+				}
+				v = e.members
+			}
+
+			if len(open) == 0 {
+				root = map[string]interface{}{e.name: v}
+				break
+			}
+			siblings := open[len(open)-1].members
+			switch prior := siblings[e.name].(type) {
+			case nil:
+				siblings[e.name] = v
+			case []interface{}:
+				siblings[e.name] = append(prior, v)
+			default:
+				siblings[e.name] = []interface{}{prior, v}
+			}
+		}
+	}
+
+	if root == nil {
+		return nil, NotValidXMLInputError
+	}
+	return root, nil
+}
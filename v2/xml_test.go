@@ -0,0 +1,79 @@
+package flatten
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFlattenXML(t *testing.T) {
+	cases := []struct {
+		src  string
+		conv XMLConvention
+		want map[string]interface{}
+		err  error
+	}{
+		// 1
+		{
+			`<?xml version="1.0"?>
+			<feed xmlns="http://www.w3.org/2005/Atom" lang="en">
+				<title>News</title>
+				<entry id="1"><title>One</title></entry>
+				<entry id="2"><title>Two</title><link href="/two"/></entry>
+				<empty/>
+			</feed>`,
+			DefaultXMLConvention,
+			map[string]interface{}{
+				"feed.@lang":              "en",
+				"feed.title":              "News",
+				"feed.entry.0.@id":        "1",
+				"feed.entry.0.title":      "One",
+				"feed.entry.1.@id":        "2",
+				"feed.entry.1.title":      "Two",
+				"feed.entry.1.link.@href": "/two",
+				"feed.empty":              "",
+			},
+			nil,
+		},
+		// 2 -- text beside attributes and children
+		{
+			`<p class="x">Hello <b>big</b> world<![CDATA[!]]></p>`,
+			DefaultXMLConvention,
+			map[string]interface{}{
+				"p.@class": "x",
+				"p.b":      "big",
+				"p.#text":  "Hello  world!",
+			},
+			nil,
+		},
+		// 3
+		{
+			`<a n="1">t</a>`,
+			XMLConvention{Attr: "-", Text: "value"},
+			map[string]interface{}{"a.-n": "1", "a.value": "t"},
+			nil,
+		},
+		// 4
+		{`<a/><b/>`, DefaultXMLConvention, nil, NotValidXMLInputError},
+		// 5
+		{`text`, DefaultXMLConvention, nil, NotValidXMLInputError},
+		// 6
+		{``, DefaultXMLConvention, nil, NotValidXMLInputError},
+	}
+
+	for i, test := range cases {
+		got, err := FlattenXML(strings.NewReader(test.src), "", DotStyle, test.conv)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%d: error mismatch, got: [%v], wanted: [%v]", i+1, err, test.err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%d: mismatch, got: %v wanted: %v", i+1, got, test.want)
+		}
+	}
+
+	if _, err := FlattenXML(strings.NewReader(`<a><b></a>`), "", DotStyle, DefaultXMLConvention); err == nil {
+		t.Errorf("mismatched tags: expected an error")
+	}
+}